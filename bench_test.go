@@ -15,6 +15,7 @@ import (
 	"compress/gzip"
 	"io/ioutil"
 	"os"
+	"strconv"
 	"strings"
 	"testing"
 )
@@ -207,6 +208,54 @@ func BenchmarkCodeUnmarshal(b *testing.B) {
 	b.SetBytes(int64(len(codeJSON)))
 }
 
+func BenchmarkCodeUnmarshalCaseSensitive(b *testing.B) {
+	if codeJSON == nil {
+		b.StopTimer()
+		codeInit()
+		b.StartTimer()
+	}
+	opts := DecodeOptions{CaseSensitive: true}
+	for i := 0; i < b.N; i++ {
+		var r codeResponse
+		if err := UnmarshalWithOptions(codeJSON, &r, opts); err != nil {
+			b.Fatal("UnmarshalWithOptions:", err)
+		}
+	}
+	b.SetBytes(int64(len(codeJSON)))
+}
+
+func BenchmarkCodeUnmarshalDisallowUnknownFields(b *testing.B) {
+	if codeJSON == nil {
+		b.StopTimer()
+		codeInit()
+		b.StartTimer()
+	}
+	opts := DecodeOptions{DisallowUnknownFields: true}
+	for i := 0; i < b.N; i++ {
+		var r codeResponse
+		if err := UnmarshalWithOptions(codeJSON, &r, opts); err != nil {
+			b.Fatal("UnmarshalWithOptions:", err)
+		}
+	}
+	b.SetBytes(int64(len(codeJSON)))
+}
+
+func BenchmarkCodeUnmarshalSinglePass(b *testing.B) {
+	if codeJSON == nil {
+		b.StopTimer()
+		codeInit()
+		b.StartTimer()
+	}
+	opts := DecodeOptions{SinglePass: true}
+	for i := 0; i < b.N; i++ {
+		var r codeResponse
+		if err := UnmarshalWithOptions(codeJSON, &r, opts); err != nil {
+			b.Fatal("UnmarshalWithOptions:", err)
+		}
+	}
+	b.SetBytes(int64(len(codeJSON)))
+}
+
 func BenchmarkCodeUnmarshalReuse(b *testing.B) {
 	if codeJSON == nil {
 		b.StopTimer()
@@ -264,3 +313,164 @@ func BenchmarkIssue10335(b *testing.B) {
 		}
 	}
 }
+
+var longStringsJSON []byte
+var indentedJSON []byte
+
+func longStringsInit() {
+	var buf bytes.Buffer
+
+	text := strings.Repeat("The quick brown fox jumps over the lazy dog. ", 40)
+	buf.WriteString(`{"id": "doc", "records": [`)
+	for i := 0; i < 100; i++ {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		buf.WriteString(`{"name": "record", "text": "` + text + `", "escaped": "` + text + `\n\t\"end\""}`)
+	}
+	buf.WriteString(`], "last": "` + text + `"}`)
+	longStringsJSON = buf.Bytes()
+
+	if codeJSON == nil {
+		codeInit()
+	}
+	buf = bytes.Buffer{}
+	if err := Indent(&buf, codeJSON, "", "        "); err != nil {
+		panic("indent code.json: " + err.Error())
+	}
+	indentedJSON = buf.Bytes()
+}
+
+func BenchmarkValidateLongStrings(b *testing.B) {
+	if longStringsJSON == nil {
+		b.StopTimer()
+		longStringsInit()
+		b.StartTimer()
+	}
+	b.SetBytes(int64(len(longStringsJSON)))
+	for i := 0; i < b.N; i++ {
+		if err := Validate(longStringsJSON); err != nil {
+			b.Fatal("Validate:", err)
+		}
+	}
+}
+
+func BenchmarkValidateIndented(b *testing.B) {
+	if indentedJSON == nil {
+		b.StopTimer()
+		longStringsInit()
+		b.StartTimer()
+	}
+	b.SetBytes(int64(len(indentedJSON)))
+	for i := 0; i < b.N; i++ {
+		if err := Validate(indentedJSON); err != nil {
+			b.Fatal("Validate:", err)
+		}
+	}
+}
+
+func BenchmarkFindKeyLongStrings(b *testing.B) {
+	if longStringsJSON == nil {
+		b.StopTimer()
+		longStringsInit()
+		b.StartTimer()
+	}
+	b.SetBytes(int64(len(longStringsJSON)))
+	for i := 0; i < b.N; i++ {
+		if _, err := FindKey(longStringsJSON, "last"); err != nil {
+			b.Fatal("FindKey:", err)
+		}
+	}
+}
+
+func BenchmarkSimpleUnmarshalLongStrings(b *testing.B) {
+	if longStringsJSON == nil {
+		b.StopTimer()
+		longStringsInit()
+		b.StartTimer()
+	}
+	b.SetBytes(int64(len(longStringsJSON)))
+	for i := 0; i < b.N; i++ {
+		if _, err := SimpleUnmarshal(longStringsJSON); err != nil {
+			b.Fatal("SimpleUnmarshal:", err)
+		}
+	}
+}
+
+func BenchmarkSimpleUnmarshalIndented(b *testing.B) {
+	if indentedJSON == nil {
+		b.StopTimer()
+		longStringsInit()
+		b.StartTimer()
+	}
+	b.SetBytes(int64(len(indentedJSON)))
+	for i := 0; i < b.N; i++ {
+		if _, err := SimpleUnmarshal(indentedJSON); err != nil {
+			b.Fatal("SimpleUnmarshal:", err)
+		}
+	}
+}
+
+var wideMap map[string]interface{}
+
+func wideMapInit() {
+	wideMap = make(map[string]interface{}, 1000)
+	for i := 0; i < 1000; i++ {
+		key := "field" + strconv.Itoa(i)
+		switch i % 3 {
+		case 0:
+			wideMap[key] = i
+		case 1:
+			wideMap[key] = key
+		case 2:
+			wideMap[key] = []interface{}{true, nil, 1.5}
+		}
+	}
+}
+
+func benchmarkMarshalWideMap(b *testing.B, opts EncodeOptions) {
+	if wideMap == nil {
+		b.StopTimer()
+		wideMapInit()
+		b.StartTimer()
+	}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := MarshalWithOptions(wideMap, opts); err != nil {
+			b.Fatal("MarshalWithOptions:", err)
+		}
+	}
+}
+
+func BenchmarkMarshalWideMapSorted(b *testing.B) {
+	benchmarkMarshalWideMap(b, DefaultEncodeOptions())
+}
+
+func BenchmarkMarshalWideMapUnsorted(b *testing.B) {
+	opts := DefaultEncodeOptions()
+	opts.SortMapKeys = false
+	benchmarkMarshalWideMap(b, opts)
+}
+
+// small containers, where the cost of entering each one shows
+type smallContainers struct {
+	Points [][2]int             `json:"points"`
+	Tags   []map[string]int     `json:"tags"`
+	Pairs  []struct{ A, B int } `json:"pairs"`
+}
+
+func BenchmarkMarshalSmallContainers(b *testing.B) {
+	var v smallContainers
+
+	for i := 0; i < 1000; i++ {
+		v.Points = append(v.Points, [2]int{i, -i})
+		v.Tags = append(v.Tags, map[string]int{"n": i})
+		v.Pairs = append(v.Pairs, struct{ A, B int }{i, i + 1})
+	}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := Marshal(&v); err != nil {
+			b.Fatal("Marshal:", err)
+		}
+	}
+}
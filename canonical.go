@@ -0,0 +1,207 @@
+//  Copyright (c) 2026 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package json
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// Canonicalize returns data in the RFC 8785 JSON Canonicalization Scheme form:
+// no whitespace, object members sorted by the UTF-16 code units of their keys,
+// numbers formatted as ECMAScript does and strings with minimal escaping.
+// data must be valid I-JSON: valid UTF-8, no duplicate keys, and numbers
+// representable as IEEE 754 doubles, which is what they are converted to.
+func Canonicalize(data []byte) ([]byte, error) {
+	var tape Tape
+
+	if err := ValidateStrict(data); err != nil {
+		return nil, err
+	}
+	if err := SetTape(&tape, data); err != nil {
+		return nil, err
+	}
+	if len(tape.nodes) == 0 {
+		return nil, &SyntaxError{"unexpected end of JSON input", int64(len(data))}
+	}
+	return tape.appendCanonical(make([]byte, 0, len(data)), 0)
+}
+
+// MarshalCanonical is like Marshal, but returns the canonical form of the encoding.
+func MarshalCanonical(v interface{}) ([]byte, error) {
+	opts := DefaultEncodeOptions()
+	opts.EscapeHTML = false
+	opts.SortMapKeys = false
+	b, err := MarshalWithOptions(v, opts)
+	if err != nil {
+		return nil, err
+	}
+	return Canonicalize(b)
+}
+
+// an object member, while canonicalizing
+type canonicalMember struct {
+	key   string
+	value int
+}
+
+// appendCanonical appends the canonical form of the value at node n
+func (tape *Tape) appendCanonical(dst []byte, n int) ([]byte, error) {
+	var err error
+
+	node := &tape.nodes[n]
+	switch node.kind {
+	case tapeObject:
+		var members []canonicalMember
+
+		for i := n + 1; i < node.next; i = tape.nodes[i+1].next {
+			key, ok := unquoteBytes(tape.data[tape.nodes[i].start:tape.nodes[i].end])
+			if !ok {
+				return nil, fmt.Errorf("json: invalid key %s", tape.data[tape.nodes[i].start:tape.nodes[i].end])
+			}
+			members = append(members, canonicalMember{string(key), i + 1})
+		}
+		sort.Slice(members, func(i, j int) bool {
+			return lessUTF16(members[i].key, members[j].key)
+		})
+		dst = append(dst, '{')
+		for i := range members {
+			if i > 0 {
+				if members[i].key == members[i-1].key {
+					return nil, fmt.Errorf("json: duplicate key %q", members[i].key)
+				}
+				dst = append(dst, ',')
+			}
+			dst = appendCanonicalString(dst, members[i].key)
+			dst = append(dst, ':')
+			dst, err = tape.appendCanonical(dst, members[i].value)
+			if err != nil {
+				return nil, err
+			}
+		}
+		return append(dst, '}'), nil
+	case tapeArray:
+		dst = append(dst, '[')
+		for i := n + 1; i < node.next; i = tape.nodes[i].next {
+			if i > n+1 {
+				dst = append(dst, ',')
+			}
+			dst, err = tape.appendCanonical(dst, i)
+			if err != nil {
+				return nil, err
+			}
+		}
+		return append(dst, ']'), nil
+	case tapeString:
+		s, ok := unquoteBytes(tape.data[node.start:node.end])
+		if !ok {
+			return nil, fmt.Errorf("json: invalid string %s", tape.data[node.start:node.end])
+		}
+		return appendCanonicalString(dst, string(s)), nil
+	}
+
+	literal := tape.data[node.start:node.end]
+	switch literal[0] {
+	case 't', 'f', 'n':
+		return append(dst, literal...), nil
+	}
+	f, err := strconv.ParseFloat(string(literal), 64)
+	if err != nil {
+		return nil, fmt.Errorf("json: number %s cannot be represented as a double", literal)
+	}
+	return appendES6Number(dst, f), nil
+}
+
+// lessUTF16 compares strings by their UTF-16 code units
+func lessUTF16(a, b string) bool {
+	for a != "" && b != "" {
+		ra, na := utf8.DecodeRuneInString(a)
+		rb, nb := utf8.DecodeRuneInString(b)
+		if ra != rb {
+
+			// only runes outside the basic plane change order, with their high surrogate
+			ua, ub := ra, rb
+			if ua >= 0x10000 {
+				ua, _ = utf16.EncodeRune(ua)
+			}
+			if ub >= 0x10000 {
+				ub, _ = utf16.EncodeRune(ub)
+			}
+			if ua != ub {
+				return ua < ub
+			}
+			return ra < rb
+		}
+		a = a[na:]
+		b = b[nb:]
+	}
+	return b != ""
+}
+
+// appendCanonicalString escapes only quotes, backslashes and control characters,
+// the latter using the short forms where they exist
+func appendCanonicalString(dst []byte, s string) []byte {
+	dst = append(dst, '"')
+	start := 0
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c >= 0x20 && c != '"' && c != '\\' {
+			continue
+		}
+		dst = append(dst, s[start:i]...)
+		switch c {
+		case '"', '\\':
+			dst = append(dst, '\\', c)
+		case '\b':
+			dst = append(dst, '\\', 'b')
+		case '\f':
+			dst = append(dst, '\\', 'f')
+		case '\n':
+			dst = append(dst, '\\', 'n')
+		case '\r':
+			dst = append(dst, '\\', 'r')
+		case '\t':
+			dst = append(dst, '\\', 't')
+		default:
+			dst = append(dst, '\\', 'u', '0', '0', hex[c>>4], hex[c&0xF])
+		}
+		start = i + 1
+	}
+	dst = append(dst, s[start:]...)
+	return append(dst, '"')
+}
+
+// appendES6Number formats f as ECMAScript's Number.prototype.toString does
+func appendES6Number(dst []byte, f float64) []byte {
+	if f == 0 {
+		return append(dst, '0')
+	}
+	abs := math.Abs(f)
+	format := byte('f')
+	if abs < 1e-6 || abs >= 1e21 {
+		format = 'e'
+	}
+	start := len(dst)
+	dst = strconv.AppendFloat(dst, f, format, -1, 64)
+	if format == 'e' {
+
+		// clean up e-09 to e-9
+		n := len(dst)
+		if n-start >= 4 && dst[n-4] == 'e' && dst[n-3] == '-' && dst[n-2] == '0' {
+			dst[n-2] = dst[n-1]
+			dst = dst[:n-1]
+		}
+	}
+	return dst
+}
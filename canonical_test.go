@@ -0,0 +1,117 @@
+//  Copyright (c) 2026 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package json
+
+import (
+	"math"
+	"testing"
+)
+
+// the examples in RFC 8785
+var canonicalIn = `{
+  "numbers": [333333333.33333329, 1E30, 4.50,
+              2e-3, 0.000000000000000000000000001],
+  "string": "\u20ac$\u000F\u000aA'\u0042\u0022\u005c\\\"\/",
+  "literals": [null, true, false]
+}`
+
+var canonicalOut = `{"literals":[null,true,false],"numbers":[333333333.3333333,1e+30,4.5,0.002,1e-27],"string":"€$\u000f\nA'B\"\\\\\"/"}`
+
+var canonicalSortIn = `{"\u20ac": "Euro Sign", "\r": "Carriage Return", "\ufb33": "Hebrew Letter Dalet With Dagesh", "1": "One", "\ud83d\ude00": "Emoji: Grinning Face", "\u0080": "Control", "\u00f6": "Latin Small Letter O With Diaeresis"}`
+
+var canonicalSortValues = []string{"Carriage Return", "One", "Control",
+	"Latin Small Letter O With Diaeresis", "Euro Sign", "Emoji: Grinning Face",
+	"Hebrew Letter Dalet With Dagesh"}
+
+var es6Numbers = []struct {
+	bits uint64
+	out  string
+}{
+	{0x0000000000000000, "0"},
+	{0x8000000000000000, "0"},
+	{0x0000000000000001, "5e-324"},
+	{0x8000000000000001, "-5e-324"},
+	{0x7fefffffffffffff, "1.7976931348623157e+308"},
+	{0xffefffffffffffff, "-1.7976931348623157e+308"},
+	{0x4340000000000000, "9007199254740992"},
+	{0xc340000000000000, "-9007199254740992"},
+	{0x4430000000000000, "295147905179352830000"},
+	{0x44b52d02c7e14af5, "9.999999999999997e+22"},
+	{0x44b52d02c7e14af6, "1e+23"},
+	{0x44b52d02c7e14af7, "1.0000000000000001e+23"},
+	{0x444b1ae4d6e2ef4e, "999999999999999700000"},
+	{0x444b1ae4d6e2ef4f, "999999999999999900000"},
+	{0x444b1ae4d6e2ef50, "1e+21"},
+	{0x3eb0c6f7a0b5ed8c, "9.999999999999997e-7"},
+	{0x3eb0c6f7a0b5ed8d, "0.000001"},
+	{0x41b3de4355555553, "333333333.3333332"},
+	{0x41b3de4355555554, "333333333.33333325"},
+	{0x41b3de4355555555, "333333333.3333333"},
+	{0x41b3de4355555556, "333333333.3333334"},
+	{0x41b3de4355555557, "333333333.33333343"},
+	{0xbecbf647612f3696, "-0.0000033333333333333333"},
+	{0x43143ff3c1cb0959, "1424953923781206.2"},
+}
+
+// tests
+
+func TestCanonicalize(t *testing.T) {
+	out, err := Canonicalize([]byte(canonicalIn))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(out) != canonicalOut {
+		t.Fatalf("expected %s got %s", canonicalOut, out)
+	}
+
+	out, err = Canonicalize([]byte(canonicalSortIn))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tape, err := NewTape(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, exp := range canonicalSortValues {
+		if got := string(tape.value(2*i + 2)); got != `"`+exp+`"` {
+			t.Errorf("member %v: expected %q, got %s", i, exp, got)
+		}
+	}
+
+	for _, in := range []string{`{"a": 1, "a": 2}`, `1e400`, "\"\xff\"", ``, `[1,]`} {
+		if _, err := Canonicalize([]byte(in)); err == nil {
+			t.Errorf("%q: expected error", in)
+		}
+	}
+}
+
+func TestES6Number(t *testing.T) {
+	for _, tt := range es6Numbers {
+		f := math.Float64frombits(tt.bits)
+		if out := string(appendES6Number(nil, f)); out != tt.out {
+			t.Errorf("%016x: expected %s got %s", tt.bits, tt.out, out)
+		}
+	}
+}
+
+func TestMarshalCanonical(t *testing.T) {
+	v := map[string]interface{}{
+		"b": []interface{}{1.0, 1e21, "<&>"},
+		"a": map[string]int{"\u00f6": 1, "z": 2},
+	}
+	out, err := MarshalCanonical(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	exp := `{"a":{"z":2,"ö":1},"b":[1,1e+21,"<&>"]}`
+	if string(out) != exp {
+		t.Fatalf("expected %s got %s", exp, out)
+	}
+}
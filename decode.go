@@ -8,7 +8,7 @@
 package json
 
 import (
-	"bytes"
+	"context"
 	"encoding"
 	"encoding/base64"
 	"errors"
@@ -39,6 +39,12 @@ import (
 // keys to the keys used by Marshal (either the struct field name or its tag),
 // preferring an exact match but also accepting a case-insensitive match.
 // Unmarshal will only set exported fields of the struct.
+// A field whose tag has the "required" option must be present in each
+// object decoded into the struct, and Unmarshal returns a MissingFieldsError
+// listing every absent required field. A field whose tag ends with a
+// "default=" option is set from the rest of the tag when absent: the
+// default is JSON text, except for string fields, and pointers to strings,
+// where it is the string itself.
 //
 // To unmarshal JSON into an interface value,
 // Unmarshal stores one of these in the interface value:
@@ -102,6 +108,73 @@ func Unmarshal(data []byte, v interface{}) error {
 	return d.unmarshal(v)
 }
 
+// UnmarshalStrict is like Unmarshal, but returns a SyntaxError if data
+// contains invalid UTF-8 or unpaired UTF-16 surrogates in \u escapes,
+// rather than replacing them with U+FFFD.
+func UnmarshalStrict(data []byte, v interface{}) error {
+	var d decodeState
+	var scan scanner
+
+	setScanner(&scan, data)
+	scan.strict = true
+	err := checkValid(data, &scan)
+	if err != nil {
+		return err
+	}
+
+	d.init(data)
+	return d.unmarshal(v)
+}
+
+// DecodeOptions selects how UnmarshalWithOptions decodes values.
+// The zero value decodes as Unmarshal does.
+type DecodeOptions struct {
+	// DisallowUnknownFields causes an UnknownFieldError when an object
+	// key matches no field of the struct it is decoded into
+	DisallowUnknownFields bool
+
+	// CaseSensitive only matches object keys to struct fields with exactly the same name,
+	// rather than preferring an exact match but accepting a case-insensitive one
+	CaseSensitive bool
+
+	// Registry, if set, supplies custom decoders for the types registered with it
+	Registry *Registry
+
+	// Context is passed to the UnmarshalJSONContext methods of values implementing UnmarshalerContext
+	Context context.Context
+
+	// SinglePass validates the input while decoding it, rather than in a separate
+	// pass beforehand, which saves scanning it twice. Errors are then reported
+	// as they are found: v may have been partially filled when a SyntaxError is
+	// returned, and an error that stops decoding hides a later syntax error.
+	SinglePass bool
+}
+
+// UnmarshalWithOptions is like Unmarshal, but decodes data as specified by opts.
+func UnmarshalWithOptions(data []byte, v interface{}, opts DecodeOptions) error {
+	var d decodeState
+
+	if !opts.SinglePass {
+		var scan scanner
+
+		setScanner(&scan, data)
+		err := checkValid(data, &scan)
+		if err != nil {
+			return err
+		}
+	}
+
+	d.init(data)
+	d.opts = opts
+	d.scan.checkTop = opts.SinglePass
+	return d.unmarshal(v)
+}
+
+// UnmarshalContext is like Unmarshal, but passes ctx to the values implementing UnmarshalerContext
+func UnmarshalContext(ctx context.Context, data []byte, v interface{}) error {
+	return UnmarshalWithOptions(data, v, DecodeOptions{Context: ctx})
+}
+
 // Unmarshaler is the interface implemented by types
 // that can unmarshal a JSON description of themselves.
 // The input can be assumed to be a valid encoding of
@@ -111,16 +184,44 @@ type Unmarshaler interface {
 	UnmarshalJSON([]byte) error
 }
 
+// UnmarshalerContext is the interface implemented by types that unmarshal
+// themselves differently depending on a context supplied by the caller.
+// The context is the one given to UnmarshalContext, DecodeOptions or
+// Decoder.SetContext, or context.Background() if there is none.
+// UnmarshalJSONContext is used in preference to UnmarshalJSON.
+type UnmarshalerContext interface {
+	UnmarshalJSONContext(ctx context.Context, data []byte) error
+}
+
+// contextUnmarshaler adapts an UnmarshalerContext to the Unmarshaler interface
+type contextUnmarshaler struct {
+	u   UnmarshalerContext
+	ctx context.Context
+}
+
+func (cu *contextUnmarshaler) UnmarshalJSON(data []byte) error {
+	return cu.u.UnmarshalJSONContext(cu.ctx, data)
+}
+
 // An UnmarshalTypeError describes a JSON value that was
 // not appropriate for a value of a specific Go type.
 type UnmarshalTypeError struct {
-	Value  string       // description of JSON value - "bool", "array", "number -5"
-	Type   reflect.Type // type of Go value it could not be assigned to
-	Offset int64        // error occurred after reading Offset bytes
+	Value   string       // description of JSON value - "bool", "array", "number -5"
+	Type    reflect.Type // type of Go value it could not be assigned to
+	Offset  int64        // error occurred after reading Offset bytes
+	Pointer string       // JSON pointer of the value
+	Field   string       // dotted Go names of the struct fields leading to the value
 }
 
 func (e *UnmarshalTypeError) Error() string {
-	return "json: cannot unmarshal " + e.Value + " into Go value of type " + e.Type.String()
+	s := "json: cannot unmarshal " + e.Value
+	if e.Pointer != "" {
+		s += " at " + strconv.Quote(e.Pointer)
+	}
+	if e.Field != "" {
+		return s + " into Go struct field " + e.Field + " of type " + e.Type.String()
+	}
+	return s + " into Go value of type " + e.Type.String()
 }
 
 // An UnmarshalFieldError describes a JSON object key that
@@ -136,6 +237,39 @@ func (e *UnmarshalFieldError) Error() string {
 	return "json: cannot unmarshal object key " + strconv.Quote(e.Key) + " into unexported field " + e.Field.Name + " of type " + e.Type.String()
 }
 
+// An UnknownFieldError describes an object key that matches no field
+// of the struct being decoded, when unknown fields are disallowed.
+type UnknownFieldError struct {
+	Key     string       // the unknown key
+	Pointer string       // JSON pointer of the member with the unknown key
+	Type    reflect.Type // the struct type
+	Offset  int64        // error occurred after reading Offset bytes
+}
+
+func (e *UnknownFieldError) Error() string {
+	return "json: unknown field " + strconv.Quote(e.Key) + " at " + strconv.Quote(e.Pointer) + " for Go value of type " + e.Type.String()
+}
+
+// A MissingFieldsError lists the members for fields tagged as required
+// that were absent from the objects being decoded.
+type MissingFieldsError struct {
+	Pointers []string // JSON pointers of the missing members
+}
+
+func (e *MissingFieldsError) Error() string {
+	s := "json: missing required field"
+	if len(e.Pointers) > 1 {
+		s += "s"
+	}
+	for i, p := range e.Pointers {
+		if i > 0 {
+			s += ","
+		}
+		s += " " + strconv.Quote(p)
+	}
+	return s
+}
+
 // An InvalidUnmarshalError describes an invalid argument passed to Unmarshal.
 // (The argument to Unmarshal must be a non-nil pointer.)
 type InvalidUnmarshalError struct {
@@ -161,6 +295,9 @@ func (d *decodeState) unmarshal(v interface{}) (err error) {
 			}
 			err = r.(error)
 		}
+		if d.errOffset > 0 {
+			d.setErrorPath(reflect.TypeOf(v))
+		}
 	}()
 
 	rv := reflect.ValueOf(v)
@@ -169,9 +306,16 @@ func (d *decodeState) unmarshal(v interface{}) (err error) {
 	}
 
 	d.scan.reset()
+	d.missing = nil
 	// We decode rv not rv.Elem because the Unmarshaler interface
 	// test must be applied at the top level of the value.
 	d.value(rv)
+	if d.opts.SinglePass {
+		d.scanEnd()
+	}
+	if d.savedError == nil && len(d.missing) > 0 {
+		return &MissingFieldsError{d.missingPointers()}
+	}
 	return d.savedError
 }
 
@@ -256,6 +400,198 @@ type decodeState struct {
 	scan       scanner
 	savedError error
 	useNumber  bool
+	opts       DecodeOptions
+
+	// offset at which the saved error occurred, if its path is to be worked out, or 0
+	errOffset int
+
+	// the required fields found missing so far
+	missing []missingField
+}
+
+// a missingField is a required field absent from the object ending at offset
+type missingField struct {
+	offset int
+	name   string
+}
+
+// pathSegment is an object key or an array index in the path to a value
+type pathSegment struct {
+	key   []byte
+	index int // -1 for object keys
+}
+
+// pathsAt returns the paths to the values holding the bytes of data at the
+// given offsets, which must be in increasing order.
+// Paths are only worked out once errors are found, rather than tracked
+// for every value decoded.
+func pathsAt(data []byte, offsets []int) [][]pathSegment {
+	var sc scanner
+
+	// one segment for each open container, the index of the current element
+	// for arrays, or the current key for objects
+	var stack []pathSegment
+
+	paths := make([][]pathSegment, 0, len(offsets))
+	scan := setScanner(&sc, data)
+	scan.reset()
+	keyStart := -1
+	for scan.offset < len(data) && len(paths) < len(offsets) {
+		i := scan.offset
+		for len(paths) < len(offsets) && offsets[len(paths)] < i {
+			paths = append(paths, currentPath(stack, scan.parseState))
+		}
+		c := data[i]
+		scan.offset++
+		op := scan.step(scan, c)
+
+		// keys end at the first interesting byte following them
+		if keyStart >= 0 && op != scanContinue {
+			stack[len(stack)-1].key, _ = unquoteBytes(data[keyStart:i])
+			keyStart = -1
+		}
+		n := len(scan.parseState)
+		switch op {
+		case scanBeginLiteral:
+			if n == 0 {
+				break
+			}
+			if scan.parseState[n-1] == parseObjectKey {
+				stack[n-1].key = nil
+				keyStart = i
+			} else if scan.parseState[n-1] == parseArrayValue {
+				stack[n-1].index++
+			}
+		case scanBeginObject, scanBeginArray:
+			if n > 1 && scan.parseState[n-2] == parseArrayValue {
+				stack[n-2].index++
+			}
+			stack = append(stack, pathSegment{index: -1})
+		case scanObjectValue:
+			stack[n-1].key = nil
+		case scanEndObject, scanEndArray:
+			stack = stack[:n]
+		case scanError:
+			scan.offset = len(data)
+		}
+	}
+	for len(paths) < len(offsets) {
+		paths = append(paths, currentPath(stack, scan.parseState))
+	}
+	return paths
+}
+
+// currentPath returns the path made of the current elements and members
+// of the open containers in stack
+func currentPath(stack []pathSegment, parseState []int) []pathSegment {
+	var path []pathSegment
+
+	for i, p := range stack {
+		if parseState[i] == parseArrayValue {
+			if p.index >= 0 {
+				path = append(path, p)
+			}
+		} else if p.key != nil {
+			path = append(path, pathSegment{key: p.key, index: -1})
+		}
+	}
+	return path
+}
+
+// pathPointer returns the JSON pointer for path
+func pathPointer(path []pathSegment) string {
+	var b []byte
+
+	for _, p := range path {
+		b = append(b, '/')
+		if p.index >= 0 {
+			b = strconv.AppendInt(b, int64(p.index), 10)
+			continue
+		}
+		b = appendPointerToken(b, string(p.key))
+	}
+	return string(b)
+}
+
+// pathFields returns the dotted Go names of the struct fields matched by the
+// keys in path, when decoding into a value of type t
+func pathFields(t reflect.Type, path []pathSegment, caseSensitive bool) string {
+	var s string
+
+	for _, p := range path {
+		for t.Kind() == reflect.Ptr {
+			t = t.Elem()
+		}
+		switch t.Kind() {
+		case reflect.Map, reflect.Slice, reflect.Array:
+			t = t.Elem()
+			continue
+		case reflect.Struct:
+			if p.index < 0 {
+				break
+			}
+			fallthrough
+		default:
+			return s
+		}
+		fields := cachedTypeFields(t)
+		if fi, ok := fields.lookup(p.key, caseSensitive); ok {
+			f := &fields.list[fi]
+			if s != "" {
+				s += "."
+			}
+			s += f.goPath
+			t = f.typ
+		} else if fields.inlineMap != nil {
+			t = typeByIndex(t, fields.inlineMap).Elem()
+		} else {
+			return s
+		}
+	}
+	return s
+}
+
+// setErrorPath sets the pointer and field path of the saved error,
+// from the value of type t being decoded
+func (d *decodeState) setErrorPath(t reflect.Type) {
+	path := pathsAt(d.scan.data, []int{d.errOffset - 1})[0]
+	switch e := d.savedError.(type) {
+	case *UnmarshalTypeError:
+		e.Pointer = pathPointer(path)
+		e.Field = pathFields(t, path, d.opts.CaseSensitive)
+	case *UnknownFieldError:
+		e.Pointer = pathPointer(path)
+	}
+}
+
+// missingPointers returns the JSON pointers of the missing fields
+func (d *decodeState) missingPointers() []string {
+	offsets := make([]int, len(d.missing))
+	for i, m := range d.missing {
+		offsets[i] = m.offset - 1
+	}
+	paths := pathsAt(d.scan.data, offsets)
+	pointers := make([]string, len(d.missing))
+	for i, m := range d.missing {
+		p := append([]byte(pathPointer(paths[i])), '/')
+		pointers[i] = string(appendPointerToken(p, m.name))
+	}
+	return pointers
+}
+
+// appendPointerToken appends key to b, escaped as a JSON pointer reference token
+func appendPointerToken(b []byte, key string) []byte {
+	for _, c := range []byte(key) {
+		switch c {
+		case '~':
+			b = append(b, '~', '0')
+		case '/':
+			b = append(b, '~', '1')
+		default:
+			b = append(b, c)
+		}
+	}
+	return b
 }
 
 // errPhase is used for errors that should not happen unless
@@ -267,6 +603,7 @@ func (d *decodeState) init(data []byte) *decodeState {
 	d.scan.data = data
 	d.scan.offset = 0
 	d.savedError = nil
+	d.errOffset = 0
 	return d
 }
 
@@ -280,6 +617,14 @@ func (d *decodeState) error(err error) {
 // for reporting at the end of the unmarshal.
 func (d *decodeState) saveError(err error) {
 	if d.savedError == nil {
+		switch e := err.(type) {
+		case *UnmarshalTypeError:
+			if e.Pointer == "" && e.Field == "" {
+				d.errOffset = d.scan.offset
+			}
+		case *UnknownFieldError:
+			d.errOffset = d.scan.offset
+		}
 		d.savedError = err
 	}
 }
@@ -329,9 +674,28 @@ func (d *decodeState) scanWhile(op int) int {
 			break
 		}
 	}
+
+	// only possible if the input was not validated beforehand
+	if newOp == scanError {
+		d.error(d.scan.err)
+	}
 	return newOp
 }
 
+// scanEnd consumes the input after the top-level value, failing unless it is all space
+func (d *decodeState) scanEnd() {
+	for d.scan.offset < len(d.scan.data) {
+		c := d.scan.data[d.scan.offset]
+		d.scan.offset++
+		if d.scan.step(&d.scan, c) == scanError {
+			break
+		}
+	}
+	if d.scan.eof() == scanError {
+		d.error(d.scan.err)
+	}
+}
+
 // value decodes a JSON value from d.scan.data[d.scan.offset:] into the value.
 // it updates d.scan.offset to point past the decoded value.
 func (d *decodeState) value(v reflect.Value) {
@@ -418,7 +782,8 @@ func (d *decodeState) indirect(v reflect.Value, decodingNull bool) (Unmarshaler,
 	// If v is a named type and is addressable,
 	// start with its address, so that if the type has pointer methods,
 	// we find them.
-	if v.Kind() != reflect.Ptr && v.Type().Name() != "" && v.CanAddr() {
+	// Registered types need not be named.
+	if v.Kind() != reflect.Ptr && (v.Type().Name() != "" || d.opts.Registry != nil) && v.CanAddr() {
 		v = v.Addr()
 	}
 	for {
@@ -442,7 +807,17 @@ func (d *decodeState) indirect(v reflect.Value, decodingNull bool) (Unmarshaler,
 		if v.IsNil() {
 			v.Set(reflect.New(v.Type().Elem()))
 		}
+		if u := d.opts.Registry.decoder(v); u != nil {
+			return u, nil, reflect.Value{}
+		}
 		if v.Type().NumMethod() > 0 {
+			if u, ok := v.Interface().(UnmarshalerContext); ok {
+				ctx := d.opts.Context
+				if ctx == nil {
+					ctx = context.Background()
+				}
+				return &contextUnmarshaler{u, ctx}, nil, reflect.Value{}
+			}
 			if u, ok := v.Interface().(Unmarshaler); ok {
 				return u, nil, reflect.Value{}
 			}
@@ -469,7 +844,7 @@ func (d *decodeState) array(v reflect.Value) {
 		return
 	}
 	if ut != nil {
-		d.saveError(&UnmarshalTypeError{"array", v.Type(), int64(d.scan.offset)})
+		d.saveError(&UnmarshalTypeError{Value: "array", Type: v.Type(), Offset: int64(d.scan.offset)})
 		d.scan.offset--
 		d.next()
 		return
@@ -488,7 +863,7 @@ func (d *decodeState) array(v reflect.Value) {
 		// Otherwise it's invalid.
 		fallthrough
 	default:
-		d.saveError(&UnmarshalTypeError{"array", v.Type(), int64(d.scan.offset)})
+		d.saveError(&UnmarshalTypeError{Value: "array", Type: v.Type(), Offset: int64(d.scan.offset)})
 		d.scan.offset--
 		d.next()
 		return
@@ -577,7 +952,7 @@ func (d *decodeState) object(v reflect.Value) {
 		return
 	}
 	if ut != nil {
-		d.saveError(&UnmarshalTypeError{"object", v.Type(), int64(d.scan.offset)})
+		d.saveError(&UnmarshalTypeError{Value: "object", Type: v.Type(), Offset: int64(d.scan.offset)})
 		d.scan.offset--
 		d.next() // skip over { } in input
 		return
@@ -605,7 +980,7 @@ func (d *decodeState) object(v reflect.Value) {
 			reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
 		default:
 			if !reflect.PtrTo(t.Key()).Implements(textUnmarshalerType) {
-				d.saveError(&UnmarshalTypeError{"object", v.Type(), int64(d.scan.offset)})
+				d.saveError(&UnmarshalTypeError{Value: "object", Type: v.Type(), Offset: int64(d.scan.offset)})
 				d.scan.offset--
 				d.next() // skip over { } in input
 				return
@@ -617,13 +992,22 @@ func (d *decodeState) object(v reflect.Value) {
 	case reflect.Struct:
 
 	default:
-		d.saveError(&UnmarshalTypeError{"object", v.Type(), int64(d.scan.offset)})
+		d.saveError(&UnmarshalTypeError{Value: "object", Type: v.Type(), Offset: int64(d.scan.offset)})
 		d.scan.offset--
 		d.next() // skip over { } in input
 		return
 	}
 
 	var mapElem reflect.Value
+	var fields structFields
+	var seen []bool
+
+	if v.Kind() == reflect.Struct {
+		fields = cachedTypeFields(v.Type())
+		if fields.checkPresence {
+			seen = make([]bool, len(fields.list))
+		}
+	}
 
 	for {
 		// Read opening " of string key or closing }.
@@ -647,6 +1031,7 @@ func (d *decodeState) object(v reflect.Value) {
 
 		// Figure out field corresponding to key.
 		var subv reflect.Value
+		var inlineMap reflect.Value
 		destring := false // whether the value is wrapped in a string to be decoded first
 
 		if v.Kind() == reflect.Map {
@@ -658,30 +1043,27 @@ func (d *decodeState) object(v reflect.Value) {
 			}
 			subv = mapElem
 		} else {
-			var f *field
-			fields := cachedTypeFields(v.Type())
-			for i := range fields {
-				ff := &fields[i]
-				if bytes.Equal(ff.nameBytes, key) {
-					f = ff
-					break
+			if fi, ok := fields.lookup(key, d.opts.CaseSensitive); ok {
+				f := &fields.list[fi]
+				subv = allocFieldByIndex(v, f.index)
+				destring = f.quoted
+				if seen != nil {
+					seen[fi] = true
 				}
-				if f == nil && ff.equalFold(ff.nameBytes, key) {
-					f = ff
+			} else if fields.inlineMap != nil {
+				inlineMap = allocFieldByIndex(v, fields.inlineMap)
+				if inlineMap.IsNil() {
+					inlineMap.Set(reflect.MakeMap(inlineMap.Type()))
 				}
-			}
-			if f != nil {
-				subv = v
-				destring = f.quoted
-				for _, i := range f.index {
-					if subv.Kind() == reflect.Ptr {
-						if subv.IsNil() {
-							subv.Set(reflect.New(subv.Type().Elem()))
-						}
-						subv = subv.Elem()
-					}
-					subv = subv.Field(i)
+				elemType := inlineMap.Type().Elem()
+				if !mapElem.IsValid() {
+					mapElem = reflect.New(elemType).Elem()
+				} else {
+					mapElem.Set(reflect.Zero(elemType))
 				}
+				subv = mapElem
+			} else if d.opts.DisallowUnknownFields {
+				d.saveError(&UnknownFieldError{Key: string(key), Type: v.Type(), Offset: int64(start + 1)})
 			}
 		}
 
@@ -725,7 +1107,7 @@ func (d *decodeState) object(v reflect.Value) {
 					s := string(key)
 					n, err := strconv.ParseInt(s, 10, 64)
 					if err != nil || reflect.Zero(kt).OverflowInt(n) {
-						d.saveError(&UnmarshalTypeError{"number " + s, kt, int64(start + 1)})
+						d.saveError(&UnmarshalTypeError{Value: "number " + s, Type: kt, Offset: int64(start + 1)})
 						return
 					}
 					kv = reflect.ValueOf(n).Convert(kt)
@@ -733,7 +1115,7 @@ func (d *decodeState) object(v reflect.Value) {
 					s := string(key)
 					n, err := strconv.ParseUint(s, 10, 64)
 					if err != nil || reflect.Zero(kt).OverflowUint(n) {
-						d.saveError(&UnmarshalTypeError{"number " + s, kt, int64(start + 1)})
+						d.saveError(&UnmarshalTypeError{Value: "number " + s, Type: kt, Offset: int64(start + 1)})
 						return
 					}
 					kv = reflect.ValueOf(n).Convert(kt)
@@ -742,6 +1124,8 @@ func (d *decodeState) object(v reflect.Value) {
 				}
 			}
 			v.SetMapIndex(kv, subv)
+		} else if inlineMap.IsValid() {
+			inlineMap.SetMapIndex(reflect.ValueOf(string(key)).Convert(inlineMap.Type().Key()), subv)
 		}
 
 		// Next token must be , or }.
@@ -753,6 +1137,67 @@ func (d *decodeState) object(v reflect.Value) {
 			d.error(errPhase)
 		}
 	}
+	if seen != nil {
+		d.absentFields(v, fields, seen)
+	}
+}
+
+// lookup returns the index of the field matching key, folding case
+// unless caseSensitive is set
+func (fields *structFields) lookup(key []byte, caseSensitive bool) (int, bool) {
+	if i, ok := fields.nameIndex[string(key)]; ok || caseSensitive {
+		return i, ok
+	}
+	for i := range fields.list {
+		f := &fields.list[i]
+		if f.equalFold(f.nameBytes, key) {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+// allocFieldByIndex returns the field of struct v with the given index sequence,
+// allocating any nil embedded struct pointers on the way
+func allocFieldByIndex(v reflect.Value, index []int) reflect.Value {
+	for _, i := range index {
+		if v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
+		}
+		v = v.Field(i)
+	}
+	return v
+}
+
+// absentFields records the required fields of struct v that the object
+// just decoded did not contain, and stores the defaults of the others
+func (d *decodeState) absentFields(v reflect.Value, fields structFields, seen []bool) {
+	for i := range fields.list {
+		f := &fields.list[i]
+		if seen[i] {
+			continue
+		}
+		if f.required {
+			d.missing = append(d.missing, missingField{d.scan.offset, f.name})
+		}
+		if !f.hasDefault {
+			continue
+		}
+		if f.defaultErr != nil {
+			d.saveError(f.defaultErr)
+			continue
+		}
+		dd := decodeState{useNumber: d.useNumber, opts: d.opts}
+		dd.init(f.defaultValue)
+		dd.scan.reset()
+		dd.value(allocFieldByIndex(v, f.index))
+		if dd.savedError != nil {
+			d.saveError(dd.savedError)
+		}
+	}
 }
 
 // literal consumes a literal from d.scan.data[d.scan.offset-1:], decoding into the value v.
@@ -788,7 +1233,7 @@ func (d *decodeState) convertNumber(s string) (interface{}, error) {
 
 	f, err := strconv.ParseFloat(s, 64)
 	if err != nil {
-		return nil, &UnmarshalTypeError{"number " + s, reflect.TypeOf(0.0), int64(d.scan.offset)}
+		return nil, &UnmarshalTypeError{Value: "number " + s, Type: reflect.TypeOf(0.0), Offset: int64(d.scan.offset)}
 	}
 	return f, nil
 }
@@ -821,7 +1266,7 @@ func (d *decodeState) literalStore(item []byte, v reflect.Value, fromQuoted bool
 			if fromQuoted {
 				d.saveError(fmt.Errorf("json: invalid use of ,string struct tag, trying to unmarshal %q into %v", item, v.Type()))
 			} else {
-				d.saveError(&UnmarshalTypeError{"string", v.Type(), int64(d.scan.offset)})
+				d.saveError(&UnmarshalTypeError{Value: "string", Type: v.Type(), Offset: int64(d.scan.offset)})
 			}
 			return
 		}
@@ -856,7 +1301,7 @@ func (d *decodeState) literalStore(item []byte, v reflect.Value, fromQuoted bool
 			if fromQuoted {
 				d.saveError(fmt.Errorf("json: invalid use of ,string struct tag, trying to unmarshal %q into %v", item, v.Type()))
 			} else {
-				d.saveError(&UnmarshalTypeError{"bool", v.Type(), int64(d.scan.offset)})
+				d.saveError(&UnmarshalTypeError{Value: "bool", Type: v.Type(), Offset: int64(d.scan.offset)})
 			}
 		case reflect.Bool:
 			v.SetBool(value)
@@ -864,7 +1309,7 @@ func (d *decodeState) literalStore(item []byte, v reflect.Value, fromQuoted bool
 			if v.NumMethod() == 0 {
 				v.Set(reflect.ValueOf(value))
 			} else {
-				d.saveError(&UnmarshalTypeError{"bool", v.Type(), int64(d.scan.offset)})
+				d.saveError(&UnmarshalTypeError{Value: "bool", Type: v.Type(), Offset: int64(d.scan.offset)})
 			}
 		}
 
@@ -879,10 +1324,10 @@ func (d *decodeState) literalStore(item []byte, v reflect.Value, fromQuoted bool
 		}
 		switch v.Kind() {
 		default:
-			d.saveError(&UnmarshalTypeError{"string", v.Type(), int64(d.scan.offset)})
+			d.saveError(&UnmarshalTypeError{Value: "string", Type: v.Type(), Offset: int64(d.scan.offset)})
 		case reflect.Slice:
 			if v.Type().Elem().Kind() != reflect.Uint8 {
-				d.saveError(&UnmarshalTypeError{"string", v.Type(), int64(d.scan.offset)})
+				d.saveError(&UnmarshalTypeError{Value: "string", Type: v.Type(), Offset: int64(d.scan.offset)})
 				break
 			}
 			b := make([]byte, base64.StdEncoding.DecodedLen(len(s)))
@@ -898,7 +1343,7 @@ func (d *decodeState) literalStore(item []byte, v reflect.Value, fromQuoted bool
 			if v.NumMethod() == 0 {
 				v.Set(reflect.ValueOf(string(s)))
 			} else {
-				d.saveError(&UnmarshalTypeError{"string", v.Type(), int64(d.scan.offset)})
+				d.saveError(&UnmarshalTypeError{Value: "string", Type: v.Type(), Offset: int64(d.scan.offset)})
 			}
 		}
 
@@ -923,7 +1368,7 @@ func (d *decodeState) literalStore(item []byte, v reflect.Value, fromQuoted bool
 			if fromQuoted {
 				d.error(fmt.Errorf("json: invalid use of ,string struct tag, trying to unmarshal %q into %v", item, v.Type()))
 			} else {
-				d.error(&UnmarshalTypeError{"number", v.Type(), int64(d.scan.offset)})
+				d.error(&UnmarshalTypeError{Value: "number", Type: v.Type(), Offset: int64(d.scan.offset)})
 			}
 		case reflect.Interface:
 			n, err := d.convertNumber(s)
@@ -932,7 +1377,7 @@ func (d *decodeState) literalStore(item []byte, v reflect.Value, fromQuoted bool
 				break
 			}
 			if v.NumMethod() != 0 {
-				d.saveError(&UnmarshalTypeError{"number", v.Type(), int64(d.scan.offset)})
+				d.saveError(&UnmarshalTypeError{Value: "number", Type: v.Type(), Offset: int64(d.scan.offset)})
 				break
 			}
 			v.Set(reflect.ValueOf(n))
@@ -940,7 +1385,7 @@ func (d *decodeState) literalStore(item []byte, v reflect.Value, fromQuoted bool
 		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
 			n, err := strconv.ParseInt(s, 10, 64)
 			if err != nil || v.OverflowInt(n) {
-				d.saveError(&UnmarshalTypeError{"number " + s, v.Type(), int64(d.scan.offset)})
+				d.saveError(&UnmarshalTypeError{Value: "number " + s, Type: v.Type(), Offset: int64(d.scan.offset)})
 				break
 			}
 			v.SetInt(n)
@@ -948,7 +1393,7 @@ func (d *decodeState) literalStore(item []byte, v reflect.Value, fromQuoted bool
 		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
 			n, err := strconv.ParseUint(s, 10, 64)
 			if err != nil || v.OverflowUint(n) {
-				d.saveError(&UnmarshalTypeError{"number " + s, v.Type(), int64(d.scan.offset)})
+				d.saveError(&UnmarshalTypeError{Value: "number " + s, Type: v.Type(), Offset: int64(d.scan.offset)})
 				break
 			}
 			v.SetUint(n)
@@ -956,7 +1401,7 @@ func (d *decodeState) literalStore(item []byte, v reflect.Value, fromQuoted bool
 		case reflect.Float32, reflect.Float64:
 			n, err := strconv.ParseFloat(s, v.Type().Bits())
 			if err != nil || v.OverflowFloat(n) {
-				d.saveError(&UnmarshalTypeError{"number " + s, v.Type(), int64(d.scan.offset)})
+				d.saveError(&UnmarshalTypeError{Value: "number " + s, Type: v.Type(), Offset: int64(d.scan.offset)})
 				break
 			}
 			v.SetFloat(n)
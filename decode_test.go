@@ -6,6 +6,7 @@ package json
 
 import (
 	"bytes"
+	"context"
 	"encoding"
 	"errors"
 	"fmt"
@@ -389,7 +390,7 @@ var unmarshalTests = []unmarshalTest{
 	{in: `"g-clef: \uD834\uDD1E"`, ptr: new(string), out: "g-clef: \U0001D11E"},
 	{in: `"invalid: \uD834x\uDD1E"`, ptr: new(string), out: "invalid: \uFFFDx\uFFFD"},
 	{in: "null", ptr: new(interface{}), out: nil},
-	{in: `{"X": [1,2,3], "Y": 4}`, ptr: new(T), out: T{Y: 4}, err: &UnmarshalTypeError{"array", reflect.TypeOf(""), 7}},
+	{in: `{"X": [1,2,3], "Y": 4}`, ptr: new(T), out: T{Y: 4}, err: &UnmarshalTypeError{Value: "array", Type: reflect.TypeOf(""), Offset: 7, Pointer: "/X", Field: "X"}},
 	{in: `{"x": 1}`, ptr: new(tx), out: tx{}},
 	{in: `{"F1":1,"F2":2,"F3":3}`, ptr: new(V), out: V{F1: int64(1), F2: int32(2), F3: Number("3")}},
 	{in: `{"F1":1,"F2":2,"F3":3}`, ptr: new(V), out: V{F1: Number("1"), F2: int32(2), F3: Number("3")}, useNumber: true},
@@ -504,22 +505,22 @@ var unmarshalTests = []unmarshalTest{
 	{
 		in:  `{"abc":"abc"}`,
 		ptr: new(map[int]string),
-		err: &UnmarshalTypeError{"number abc", reflect.TypeOf(0), 2},
+		err: &UnmarshalTypeError{Value: "number abc", Type: reflect.TypeOf(0), Offset: 2, Pointer: "/abc"},
 	},
 	{
 		in:  `{"256":"abc"}`,
 		ptr: new(map[uint8]string),
-		err: &UnmarshalTypeError{"number 256", reflect.TypeOf(uint8(0)), 2},
+		err: &UnmarshalTypeError{Value: "number 256", Type: reflect.TypeOf(uint8(0)), Offset: 2, Pointer: "/256"},
 	},
 	{
 		in:  `{"128":"abc"}`,
 		ptr: new(map[int8]string),
-		err: &UnmarshalTypeError{"number 128", reflect.TypeOf(int8(0)), 2},
+		err: &UnmarshalTypeError{Value: "number 128", Type: reflect.TypeOf(int8(0)), Offset: 2, Pointer: "/128"},
 	},
 	{
 		in:  `{"-1":"abc"}`,
 		ptr: new(map[uint8]string),
-		err: &UnmarshalTypeError{"number -1", reflect.TypeOf(uint8(0)), 2},
+		err: &UnmarshalTypeError{Value: "number -1", Type: reflect.TypeOf(uint8(0)), Offset: 2, Pointer: "/-1"},
 	},
 
 	// Map keys can be encoding.TextUnmarshalers.
@@ -653,12 +654,12 @@ var unmarshalTests = []unmarshalTest{
 	{
 		in:  `{"2009-11-10T23:00:00Z": "hello world"}`,
 		ptr: &map[Point]string{},
-		err: &UnmarshalTypeError{"object", reflect.TypeOf(map[Point]string{}), 1},
+		err: &UnmarshalTypeError{Value: "object", Type: reflect.TypeOf(map[Point]string{}), Offset: 1},
 	},
 	{
 		in:  `{"asdf": "hello world"}`,
 		ptr: &map[unmarshaler]string{},
-		err: &UnmarshalTypeError{"object", reflect.TypeOf(map[unmarshaler]string{}), 1},
+		err: &UnmarshalTypeError{Value: "object", Type: reflect.TypeOf(map[unmarshaler]string{}), Offset: 1},
 	},
 
 	// related to issue 13783.
@@ -902,6 +903,383 @@ func TestUnmarshal(t *testing.T) {
 	}
 }
 
+func TestUnmarshalStrict(t *testing.T) {
+	for _, tt := range strictTests {
+		var v, exp interface{}
+
+		err := UnmarshalStrict([]byte(tt.in), &v)
+		if !tt.valid {
+			if se, ok := err.(*SyntaxError); !ok || se.Offset != tt.offset {
+				t.Errorf("%q expected syntax error at %v, got %v", tt.in, tt.offset, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%q got %v", tt.in, err)
+			continue
+		}
+		Unmarshal([]byte(tt.in), &exp)
+		if !reflect.DeepEqual(v, exp) {
+			t.Errorf("%q expected %v, got %v", tt.in, exp, v)
+		}
+	}
+}
+
+type unknownInner struct {
+	Name string
+}
+
+type unknownOuter struct {
+	ID    int
+	Items []unknownInner
+	Map   map[string]unknownInner
+	Any   interface{}
+}
+
+func TestDisallowUnknownFields(t *testing.T) {
+	for _, tt := range []struct {
+		in      string
+		key     string
+		pointer string
+	}{
+		{`{"ID": 1, "Any": {"x": 1}, "Items": [{"Name": "a"}]}`, "", ""},
+		{`{"id": 1, "items": [{"name": "a"}]}`, "", ""},
+		{`{"ID": 1, "Idd": 2}`, "Idd", "/Idd"},
+		{`{"Items": [{"Name": "a"}, {"Nmae": "b"}]}`, "Nmae", "/Items/1/Nmae"},
+		{`{"Map": {"a/b": {"Name": "a", "x~": 1}}}`, "x~", "/Map/a~1b/x~0"},
+		{`{"Any": [1], "Extra": {"a": [1, 2]}, "Other": 1}`, "Extra", "/Extra"},
+	} {
+		var v unknownOuter
+
+		err := UnmarshalWithOptions([]byte(tt.in), &v, DecodeOptions{DisallowUnknownFields: true})
+		if tt.key == "" {
+			if err != nil {
+				t.Errorf("%s: got %v", tt.in, err)
+			}
+			continue
+		}
+		ue, ok := err.(*UnknownFieldError)
+		if !ok {
+			t.Errorf("%s: expected unknown field error, got %v", tt.in, err)
+			continue
+		}
+		if ue.Key != tt.key || ue.Pointer != tt.pointer {
+			t.Errorf("%s: expected %q at %q, got %q at %q", tt.in, tt.key, tt.pointer, ue.Key, ue.Pointer)
+		}
+		if !strings.HasPrefix(tt.in[ue.Offset-1:], `"`+tt.key) {
+			t.Errorf("%s: offset %v does not point at the key", tt.in, ue.Offset)
+		}
+
+		// the rest of the document is still decoded, and unknown fields are ignored by default
+		var w unknownOuter
+		if err := Unmarshal([]byte(tt.in), &w); err != nil || !reflect.DeepEqual(v, w) {
+			t.Errorf("%s: expected %v, got %v (%v)", tt.in, w, v, err)
+		}
+
+		dec := NewDecoder(strings.NewReader(tt.in))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(&v); !reflect.DeepEqual(err, ue) {
+			t.Errorf("%s: Decoder expected %v, got %v", tt.in, ue, err)
+		}
+	}
+}
+
+func TestCaseSensitiveKeys(t *testing.T) {
+	type T struct {
+		Name  string `json:"name"`
+		Value int
+	}
+
+	in := `{"NAME": "upper", "name": "exact", "value": 1, "Value": 2, "VALUE": 3}`
+	var v T
+	if err := Unmarshal([]byte(in), &v); err != nil {
+		t.Fatal(err)
+	}
+	if v.Name != "exact" || v.Value != 3 {
+		t.Errorf("case insensitive got %+v", v)
+	}
+
+	v = T{}
+	if err := UnmarshalWithOptions([]byte(in), &v, DecodeOptions{CaseSensitive: true}); err != nil {
+		t.Fatal(err)
+	}
+	if v.Name != "exact" || v.Value != 2 {
+		t.Errorf("case sensitive got %+v", v)
+	}
+
+	v = T{}
+	opts := DecodeOptions{CaseSensitive: true, DisallowUnknownFields: true}
+	err := UnmarshalWithOptions([]byte(`{"Name": "x"}`), &v, opts)
+	if ue, ok := err.(*UnknownFieldError); !ok || ue.Key != "Name" {
+		t.Errorf("expected unknown field Name, got %v", err)
+	}
+
+	v = T{}
+	dec := NewDecoder(strings.NewReader(`{"NAME": "upper", "value": 1}`))
+	dec.UseCaseSensitiveKeys()
+	if err := dec.Decode(&v); err != nil || v != (T{}) {
+		t.Errorf("Decoder got %+v, %v", v, err)
+	}
+}
+
+func TestRequiredAndDefault(t *testing.T) {
+	type Listener struct {
+		Addr string `json:"addr,required"`
+		Port int    `json:"port,default=8080"`
+	}
+	type Config struct {
+		Name      string     `json:"name,required"`
+		Mode      string     `json:"mode,default=fast,safe"`
+		Tags      []string   `json:"tags,omitempty,default=[\"a\",\"b\"]"`
+		Limit     *float64   `json:"limit,default=1.5"`
+		Owner     *string    `json:"owner,default=root"`
+		Listeners []Listener `json:"listeners"`
+		Meta      Listener   `json:"meta"`
+	}
+
+	var c Config
+	in := `{"mode": "slow", "listeners": [{"addr": ":1"}, {"port": 1}]}`
+	err := Unmarshal([]byte(in), &c)
+	me, ok := err.(*MissingFieldsError)
+	if !ok {
+		t.Fatalf("expected missing fields error, got %v", err)
+	}
+	if want := []string{"/listeners/1/addr", "/name"}; !reflect.DeepEqual(me.Pointers, want) {
+		t.Errorf("expected missing %q, got %q", want, me.Pointers)
+	}
+	if c.Mode != "slow" || c.Limit == nil || *c.Limit != 1.5 || !reflect.DeepEqual(c.Tags, []string{"a", "b"}) {
+		t.Errorf("defaults got %+v", c)
+	}
+	if c.Listeners[0].Port != 8080 || c.Listeners[1].Port != 1 {
+		t.Errorf("nested defaults got %+v", c.Listeners)
+	}
+
+	// defaults only apply to objects in the input
+	if c.Meta.Port != 0 {
+		t.Errorf("absent struct got %+v", c.Meta)
+	}
+
+	c = Config{}
+	if err := Unmarshal([]byte(`{"name": "n", "tags": null}`), &c); err != nil {
+		t.Fatal(err)
+	}
+	if c.Name != "n" || c.Mode != "fast,safe" || c.Tags != nil || c.Owner == nil || *c.Owner != "root" {
+		t.Errorf("got %+v", c)
+	}
+
+	type Bad struct {
+		N int `json:"n,default=x"`
+	}
+	var b Bad
+	err = Unmarshal([]byte(`{}`), &b)
+	if err == nil || !strings.Contains(err.Error(), "invalid default for field n in struct json.Bad") {
+		t.Errorf("expected invalid default error, got %v", err)
+	}
+
+	// the default is only checked once, with the type
+	if err2 := Unmarshal([]byte(`{}`), &b); err2 != err {
+		t.Errorf("expected the same error, got %v", err2)
+	}
+	if err := Unmarshal([]byte(`{"n": 1}`), &b); err != nil || b.N != 1 {
+		t.Errorf("expected 1, got %v %v", b.N, err)
+	}
+}
+
+func TestInline(t *testing.T) {
+	type Meta struct {
+		Owner string `json:"owner"`
+		Rev   int    `json:"rev"`
+	}
+	type Extra struct {
+		Note string `json:"note"`
+	}
+	type Doc struct {
+		ID    string                 `json:"id"`
+		Meta  Meta                   `json:"meta,inline"`
+		Extra *Extra                 `json:",inline"`
+		Rest  map[string]interface{} `json:",inline"`
+	}
+
+	in := `{"id": "d1", "owner": "me", "rev": 2, "note": "n", "x": [1, 2], "y": {"z": null}}`
+	var d Doc
+	if err := UnmarshalWithOptions([]byte(in), &d, DecodeOptions{DisallowUnknownFields: true}); err != nil {
+		t.Fatal(err)
+	}
+	want := Doc{
+		ID:    "d1",
+		Meta:  Meta{Owner: "me", Rev: 2},
+		Extra: &Extra{Note: "n"},
+		Rest: map[string]interface{}{
+			"x": []interface{}{int64(1), int64(2)},
+			"y": map[string]interface{}{"z": nil},
+		},
+	}
+	if !reflect.DeepEqual(d, want) {
+		t.Fatalf("expected %+v got %+v", want, d)
+	}
+
+	// map entries that name a field are not duplicated
+	d.Rest["id"] = "dup"
+	got, err := Marshal(d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exp := `{"id":"d1","owner":"me","rev":2,"note":"n","x":[1,2],"y":{"z":null}}`; string(got) != exp {
+		t.Errorf("expected %s got %s", exp, got)
+	}
+
+	d = Doc{Rest: map[string]interface{}{"a": 1}}
+	if got, _ = Marshal(d); string(got) != `{"id":"","owner":"","rev":0,"a":1}` {
+		t.Errorf("got %s", got)
+	}
+
+	type Bad struct {
+		Rest map[int]int `json:",inline"`
+	}
+	if got, _ = Marshal(Bad{map[int]int{1: 2}}); string(got) != `{"Rest":{"1":2}}` {
+		t.Errorf("inline ignored on int keyed map, got %s", got)
+	}
+}
+
+// rejected fails to decode anything, aborting the decoding
+type rejected struct{}
+
+func (r *rejected) UnmarshalJSON(data []byte) error {
+	return &UnmarshalTypeError{Value: "value", Type: reflect.TypeOf(r)}
+}
+
+func TestUnmarshalTypeErrorPath(t *testing.T) {
+	type Port struct {
+		Number int `json:"number"`
+	}
+	type Embedded struct {
+		Ports []Port `json:"ports"`
+	}
+	type Host struct {
+		Embedded
+		Tags  map[string]int `json:"tags"`
+		Check rejected       `json:"check"`
+	}
+
+	for _, tt := range []struct {
+		in      string
+		pointer string
+		field   string
+	}{
+		{`{"ports": [{"number": 1}, {"number": "x"}]}`, "/ports/1/number", "Embedded.Ports.Number"},
+		{`{"tags": {"a/b": true}}`, "/tags/a~1b", "Tags"},
+		{`{"ports": {}}`, "/ports", "Embedded.Ports"},
+		{`{"tags": {"a]\"}": 1, "": "x"}}`, "/tags/", "Tags"},
+		{`{"ports": [{"number": 1}, {}, {"number": [2]}]}`, "/ports/2/number", "Embedded.Ports.Number"},
+		{`{"ports": [], "PORTS": [[]]}`, "/PORTS/0", "Embedded.Ports"},
+		{`{"ports": [{"number": 1}], "check": [1, {"a": 2}]}`, "/check", "Check"},
+	} {
+		var h Host
+		err := Unmarshal([]byte(tt.in), &h)
+		ue, ok := err.(*UnmarshalTypeError)
+		if !ok {
+			t.Errorf("%s: expected UnmarshalTypeError, got %v", tt.in, err)
+			continue
+		}
+		if ue.Pointer != tt.pointer || ue.Field != tt.field {
+			t.Errorf("%s: expected %q %q, got %q %q", tt.in, tt.pointer, tt.field, ue.Pointer, ue.Field)
+		}
+		if !strings.Contains(ue.Error(), strconv.Quote(tt.pointer)) {
+			t.Errorf("%s: pointer missing from %v", tt.in, ue)
+		}
+	}
+}
+
+type localeKey struct{}
+
+// localized parses numbers with a decimal comma in the "fr" locale
+type localized float64
+
+func (l *localized) UnmarshalJSONContext(ctx context.Context, data []byte) error {
+	var s string
+	if err := Unmarshal(data, &s); err != nil {
+		return err
+	}
+	if ctx.Value(localeKey{}) == "fr" {
+		s = strings.Replace(s, ",", ".", 1)
+	}
+	f, err := strconv.ParseFloat(s, 64)
+	*l = localized(f)
+	return err
+}
+
+func (l *localized) UnmarshalJSON(data []byte) error {
+	return errors.New("UnmarshalJSON called")
+}
+
+func TestUnmarshalContext(t *testing.T) {
+	var v struct {
+		A localized
+		B []*localized
+	}
+	in := []byte(`{"A": "1.5", "B": ["2.5"]}`)
+	if err := UnmarshalContext(nil, in, &v); err != nil {
+		t.Fatal(err)
+	}
+	if v.A != 1.5 || *v.B[0] != 2.5 {
+		t.Errorf("got %v %v", v.A, *v.B[0])
+	}
+
+	ctx := context.WithValue(context.Background(), localeKey{}, "fr")
+	dec := NewDecoder(strings.NewReader(`{"A": "1,25", "B": ["3,5"]}`))
+	dec.SetContext(ctx)
+	if err := dec.Decode(&v); err != nil {
+		t.Fatal(err)
+	}
+	if v.A != 1.25 || *v.B[0] != 3.5 {
+		t.Errorf("got %v %v", v.A, *v.B[0])
+	}
+}
+
+func TestUnmarshalSinglePass(t *testing.T) {
+	opts := DecodeOptions{SinglePass: true}
+	for i, tt := range unmarshalTests {
+		if tt.ptr == nil || tt.useNumber {
+			continue
+		}
+		in := []byte(tt.in)
+		want := reflect.New(reflect.TypeOf(tt.ptr).Elem())
+		wantErr := Unmarshal(in, want.Interface())
+		v := reflect.New(reflect.TypeOf(tt.ptr).Elem())
+		err := UnmarshalWithOptions(in, v.Interface(), opts)
+		if !reflect.DeepEqual(err, wantErr) {
+			t.Errorf("#%d: %q expected error %v, got %v", i, tt.in, wantErr, err)
+			continue
+		}
+		if _, ok := err.(*SyntaxError); !ok && !reflect.DeepEqual(v.Interface(), want.Interface()) {
+			t.Errorf("#%d: %q expected %#v, got %#v", i, tt.in, want.Elem().Interface(), v.Elem().Interface())
+		}
+	}
+
+	for _, in := range []string{
+		``, ` `, `{"a": 1`, `{"a": 1}}`, `{"a": 1} x`, `[1, 2,]`, `[1 2]`, `"abc`, `tru`, `12 3`,
+		`{"a": "b\x"}`, `{"a" 1}`, `{"a": [1, {"b": nul}]}`,
+	} {
+		var v interface{}
+		wantErr := Unmarshal([]byte(in), &v)
+		err := UnmarshalWithOptions([]byte(in), &v, opts)
+		if _, ok := err.(*SyntaxError); !ok || !reflect.DeepEqual(err, wantErr) {
+			t.Errorf("%q: expected %v, got %v", in, wantErr, err)
+		}
+	}
+
+	if codeJSON == nil {
+		codeInit()
+	}
+	var r codeResponse
+	if err := UnmarshalWithOptions(codeJSON, &r, opts); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(r, codeStruct) {
+		t.Errorf("code mismatch")
+	}
+}
+
 func TestUnmarshalMarshal(t *testing.T) {
 	var v interface{}
 
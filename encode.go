@@ -12,6 +12,7 @@ package json
 
 import (
 	"bytes"
+	"context"
 	"encoding"
 	"encoding/base64"
 	"fmt"
@@ -24,6 +25,7 @@ import (
 	"sync"
 	"sync/atomic"
 	"unicode"
+	"unicode/utf16"
 	"unicode/utf8"
 )
 
@@ -34,7 +36,9 @@ import (
 // and is not a nil pointer, Marshal calls its MarshalJSON method
 // to produce JSON. If no MarshalJSON method is present but the
 // value implements encoding.TextMarshaler instead, Marshal calls
-// its MarshalText method.
+// its MarshalText method. A value implementing MarshalerContext is
+// marshaled by its MarshalJSONContext method in preference to both, with
+// the context given to MarshalContext or EncodeOptions, if any.
 // The nil pointer exception is not strictly necessary
 // but mimics a similar, necessary exception in the behavior of
 // UnmarshalJSON.
@@ -59,10 +63,14 @@ import (
 // Struct values encode as JSON objects. Each exported struct field
 // becomes a member of the object unless
 //   - the field's tag is "-", or
-//   - the field is empty and its tag specifies the "omitempty" option.
+//   - the field is empty and its tag specifies the "omitempty" option, or
+//   - the field is zero and its tag specifies the "omitzero" option.
 // The empty values are false, 0, any
 // nil pointer or interface value, and any array, slice, map, or string of
-// length zero. The object's default key string is the struct field name
+// length zero. A value is zero if it has an IsZero() bool method that
+// returns true, or otherwise if it is the zero value of its type, so that
+// "omitzero" also drops zero structs such as a zero time.Time.
+// The object's default key string is the struct field name
 // but can be specified in the struct field's tag value. The "json" key in
 // the struct field's tag value is the key name, followed by an optional comma
 // and options. Examples:
@@ -83,6 +91,10 @@ import (
 //   // Note the leading comma.
 //   Field int `json:",omitempty"`
 //
+// The "omitzero" option skips a field if it is zero, as defined above:
+//
+//	Field time.Time `json:",omitzero"`
+//
 // The "string" option signals that a field is stored as JSON inside a
 // JSON-encoded string. It applies only to fields of string, floating point,
 // integer, or boolean types. This extra level of encoding is sometimes used
@@ -94,6 +106,14 @@ import (
 // only Unicode letters, digits, and ASCII punctuation except quotation
 // marks, backslash, and comma.
 //
+// The "inline" option flattens a struct field into the enclosing object,
+// as if it were an anonymous struct field. On a field of map type with
+// string keys, it encodes the map's entries after the other fields, skipping
+// any whose key is the name of a field; Unmarshal stores the members that
+// match no field in the map, so that unknown members round-trip:
+//
+//	Extra map[string]interface{} `json:",inline"`
+//
 // Anonymous struct fields are usually marshaled as if their inner exported fields
 // were fields in the outer struct, subject to the usual Go visibility rules amended
 // as described in the next paragraph.
@@ -138,11 +158,11 @@ import (
 //
 // JSON cannot represent cyclic data structures and Marshal does not
 // handle them. Passing cyclic structures to Marshal will result in
-// an infinite recursion.
+// an UnsupportedValueError.
 //
 func Marshal(v interface{}) ([]byte, error) {
 	e := &encodeState{}
-	err := e.marshal(v, encOpts{escapeHTML: true})
+	err := e.marshal(v, encOpts{escapeHTML: true, sortMapKeys: true})
 	if err != nil {
 		return nil, err
 	}
@@ -152,7 +172,7 @@ func Marshal(v interface{}) ([]byte, error) {
 // MarshalNoEscape is like Marshal, but does not escape <, &, >
 func MarshalNoEscape(v interface{}) ([]byte, error) {
 	e := &encodeState{}
-	err := e.marshal(v, encOpts{escapeHTML: false})
+	err := e.marshal(v, encOpts{escapeHTML: false, sortMapKeys: true})
 	if err != nil {
 		return nil, err
 	}
@@ -162,7 +182,7 @@ func MarshalNoEscape(v interface{}) ([]byte, error) {
 // MarshalNoEscapeToBuffer is like Marshal, but does not escape <, &, >, and writes to a buffer
 func MarshalNoEscapeToBuffer(v interface{}, buf *bytes.Buffer) error {
 	e := &encodeState{Buffer: *buf}
-	err := e.marshal(v, encOpts{escapeHTML: false})
+	err := e.marshal(v, encOpts{escapeHTML: false, sortMapKeys: true})
 	*buf = e.Buffer
 	return err
 }
@@ -172,7 +192,7 @@ func MarshalNoEscapeToBuffer(v interface{}, buf *bytes.Buffer) error {
 // It is used by stringValue.WriteJSON
 func MarshalStringNoEscapeToBuffer(s string, buf *bytes.Buffer) error {
 	e := &encodeState{Buffer: *buf}
-	e.string(s, false)		// escapeHTML: false
+	e.string(s, encOpts{escapeHTML: false})
 	*buf = e.Buffer
 	return nil
 }
@@ -180,7 +200,7 @@ func MarshalStringNoEscapeToBuffer(s string, buf *bytes.Buffer) error {
 // This specialisation is the same as the above barring escaping HTML (to match Marshal())
 func MarshalStringToBuffer(s string, buf *bytes.Buffer) error {
 	e := &encodeState{Buffer: *buf}
-	e.string(s, true)		// escapeHTML: true
+	e.string(s, encOpts{escapeHTML: true})
 	*buf = e.Buffer
 	return nil
 }
@@ -199,6 +219,92 @@ func MarshalIndent(v interface{}, prefix, indent string) ([]byte, error) {
 	return buf.Bytes(), nil
 }
 
+// EncodeOptions selects how MarshalWithOptions and Encoder.SetOptions encode values.
+// Use DefaultEncodeOptions() as a starting point: it matches Marshal.
+type EncodeOptions struct {
+	// EscapeHTML escapes <, > and & inside strings
+	EscapeHTML bool
+
+	// SortMapKeys writes map keys in sorted order, rather than in map iteration order
+	SortMapKeys bool
+
+	// if either is set, the output is indented as Indent does
+	Prefix string
+	Indent string
+
+	// ASCIIOnly escapes all non ASCII characters in strings as \u sequences
+	ASCIIOnly bool
+
+	// FloatFormat is the strconv.FormatFloat format used for floats, one of
+	// 'e', 'E', 'f', 'g' or 'G', with the shortest precision that represents
+	// the value exactly. The zero value means 'g'.
+	FloatFormat byte
+
+	// NilSliceAsEmpty and NilMapAsEmpty encode nil slices and maps as [] and {}, rather than null.
+	// A nil []byte, which is encoded as a base64 string, is encoded as "" instead,
+	// like an empty one.
+	NilSliceAsEmpty bool
+	NilMapAsEmpty   bool
+
+	// Registry, if set, supplies custom encoders for the types registered with it
+	Registry *Registry
+
+	// Context is passed to the MarshalJSONContext methods of values implementing MarshalerContext
+	Context context.Context
+}
+
+// DefaultEncodeOptions returns the options used by Marshal
+func DefaultEncodeOptions() EncodeOptions {
+	return EncodeOptions{EscapeHTML: true, SortMapKeys: true}
+}
+
+// encOpts converts the public options into the ones passed to the encoders
+func (opts *EncodeOptions) encOpts() (encOpts, error) {
+	switch opts.FloatFormat {
+	case 0, 'e', 'E', 'f', 'g', 'G':
+	default:
+		return encOpts{}, fmt.Errorf("json: invalid float format %q", opts.FloatFormat)
+	}
+	return encOpts{
+		escapeHTML:      opts.EscapeHTML,
+		sortMapKeys:     opts.SortMapKeys,
+		asciiOnly:       opts.ASCIIOnly,
+		floatFormat:     opts.FloatFormat,
+		nilSliceAsEmpty: opts.NilSliceAsEmpty,
+		nilMapAsEmpty:   opts.NilMapAsEmpty,
+		registry:        opts.Registry,
+	}, nil
+}
+
+// MarshalWithOptions is like Marshal, but encodes v as specified by opts
+func MarshalWithOptions(v interface{}, opts EncodeOptions) ([]byte, error) {
+	eOpts, err := opts.encOpts()
+	if err != nil {
+		return nil, err
+	}
+	e := &encodeState{ctx: opts.Context}
+	err = e.marshal(v, eOpts)
+	if err != nil {
+		return nil, err
+	}
+	if opts.Prefix == "" && opts.Indent == "" {
+		return e.Bytes(), nil
+	}
+	var buf bytes.Buffer
+	err = Indent(&buf, e.Bytes(), opts.Prefix, opts.Indent)
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// MarshalContext is like Marshal, but passes ctx to the values implementing MarshalerContext
+func MarshalContext(ctx context.Context, v interface{}) ([]byte, error) {
+	opts := DefaultEncodeOptions()
+	opts.Context = ctx
+	return MarshalWithOptions(v, opts)
+}
+
 // HTMLEscape appends to dst the JSON-encoded src with <, >, &, U+2028 and U+2029
 // characters inside string literals changed to \u003c, \u003e, \u0026, \u2028, \u2029
 // so that the JSON will be safe to embed inside HTML <script> tags.
@@ -240,6 +346,15 @@ type Marshaler interface {
 	MarshalJSON() ([]byte, error)
 }
 
+// MarshalerContext is the interface implemented by types that marshal
+// themselves differently depending on a context supplied by the caller,
+// for example to select an API version. The context is the one given to
+// MarshalContext, EncodeOptions or Encoder.SetContext, or
+// context.Background() if there is none.
+type MarshalerContext interface {
+	MarshalJSONContext(ctx context.Context) ([]byte, error)
+}
+
 // An UnsupportedTypeError is returned by Marshal when attempting
 // to encode an unsupported value type.
 type UnsupportedTypeError struct {
@@ -274,23 +389,140 @@ func (e *InvalidUTF8Error) Error() string {
 }
 
 type MarshalerError struct {
-	Type reflect.Type
-	Err  error
+	Type    reflect.Type
+	Err     error
+	Pointer string // JSON pointer of the value being encoded
+	Field   string // dotted Go names of the struct fields leading to the value
 }
 
 func (e *MarshalerError) Error() string {
-	return "json: error calling MarshalJSON for type " + e.Type.String() + ": " + e.Err.Error()
+	s := "json: error calling MarshalJSON for type " + e.Type.String()
+	if e.Field != "" {
+		s += " of Go struct field " + e.Field
+	}
+	if e.Pointer != "" {
+		s += " at " + strconv.Quote(e.Pointer)
+	}
+	return s + ": " + e.Err.Error()
 }
 
+// Unwrap returns the underlying error.
+func (e *MarshalerError) Unwrap() error { return e.Err }
+
 var hex = "0123456789abcdef"
 
 // An encodeState encodes JSON into a bytes.Buffer.
 type encodeState struct {
 	bytes.Buffer // accumulated output
 	scratch      [64]byte
+
+	// Keep track of what pointers we've seen in the current recursive call
+	// path, to avoid cycles that could lead to a stack overflow. Only do
+	// the relatively expensive map operations if ptrLevel is larger than
+	// startDetectingCyclesAfter, so that we skip the work if we're within a
+	// reasonable amount of nested pointers deep.
+	ptrLevel uint
+	ptrSeen  map[ptrKey]struct{}
+
+	// passed to MarshalerContext values
+	ctx context.Context
+}
+
+// setErrorPath sets the pointer and field path of a MarshalerError raised
+// while encoding v. They are worked out from the output of v, from start on,
+// which ends where the value in error would start, rather than tracked as
+// values are encoded, which would slow down the common case.
+func (e *encodeState) setErrorPath(me *MarshalerError, v reflect.Value, start int) {
+
+	// a literal stands in for the value in error
+	out := append(e.Bytes()[start:e.Len():e.Len()], '0')
+	path := pathsAt(out, []int{len(out) - 1})[0]
+	me.Pointer = pathPointer(path)
+	me.Field = valueFields(v, path)
+}
+
+// valueFields returns the dotted Go names of the struct fields encoded
+// as the members in path, starting from v
+func valueFields(v reflect.Value, path []pathSegment) string {
+	var s string
+
+	for _, p := range path {
+		for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+			if v.IsNil() {
+				return s
+			}
+			v = v.Elem()
+		}
+		switch v.Kind() {
+		case reflect.Array, reflect.Slice:
+			if p.index < 0 || p.index >= v.Len() {
+				return s
+			}
+			v = v.Index(p.index)
+			continue
+		case reflect.Map:
+			v = mapEntry(v, string(p.key))
+			continue
+		case reflect.Struct:
+		default:
+			return s
+		}
+		fields := cachedTypeFields(v.Type())
+		if i, ok := fields.nameIndex[string(p.key)]; ok {
+			f := &fields.list[i]
+			if s != "" {
+				s += "."
+			}
+			s += f.goPath
+			v = fieldByIndex(v, f.index)
+		} else if fields.inlineMap != nil {
+			v = mapEntry(fieldByIndex(v, fields.inlineMap), string(p.key))
+		} else {
+			return s
+		}
+	}
+	return s
+}
+
+// mapEntry returns the value of the entry of map m whose key is encoded as key
+func mapEntry(m reflect.Value, key string) reflect.Value {
+	if !m.IsValid() {
+		return m
+	}
+	iter := m.MapRange()
+	for iter.Next() {
+		kv := reflectWithString{v: iter.Key()}
+		if kv.resolve() == nil && kv.s == key {
+			return iter.Value()
+		}
+	}
+	return reflect.Value{}
+}
+
+const startDetectingCyclesAfter = 1000
+
+// ptrKey identifies a pointer, map or slice being encoded.
+// Slices sharing an array are only the same value if they have the same length.
+type ptrKey struct {
+	ptr uintptr
+	len int
+	typ reflect.Type
+}
+
+// markSeen records that the value identified by key is being encoded,
+// failing if it already was further up the current path.
+func (e *encodeState) markSeen(v reflect.Value, key ptrKey) {
+	if _, ok := e.ptrSeen[key]; ok {
+		e.error(&UnsupportedValueError{v, fmt.Sprintf("encountered a cycle via %s", v.Type())})
+	}
+	if e.ptrSeen == nil {
+		e.ptrSeen = make(map[ptrKey]struct{})
+	}
+	e.ptrSeen[key] = struct{}{}
 }
 
 func (e *encodeState) marshal(v interface{}, opts encOpts) (err error) {
+	start := e.Len() // MarshalNoEscapeToBuffer may start with output in the buffer
 	defer func() {
 		if r := recover(); r != nil {
 			if _, ok := r.(runtime.Error); ok {
@@ -300,6 +532,9 @@ func (e *encodeState) marshal(v interface{}, opts encOpts) (err error) {
 				panic(s)
 			}
 			err = r.(error)
+			if me, ok := err.(*MarshalerError); ok {
+				e.setErrorPath(me, reflect.ValueOf(v), start)
+			}
 		}
 	}()
 	e.reflectValue(reflect.ValueOf(v), opts)
@@ -328,8 +563,47 @@ func isEmptyValue(v reflect.Value) bool {
 	return false
 }
 
+type isZeroer interface {
+	IsZero() bool
+}
+
+var isZeroerType = reflect.TypeOf(new(isZeroer)).Elem()
+
+// zeroFunc returns the function that reports whether a field of type t
+// is zero, for the "omitzero" option
+func zeroFunc(t reflect.Type) func(reflect.Value) bool {
+	switch {
+	case t.Kind() == reflect.Interface && t.Implements(isZeroerType):
+		return func(v reflect.Value) bool {
+			// avoid calling IsZero on a nil pointer with a value receiver
+			return v.IsNil() ||
+				v.Elem().Kind() == reflect.Ptr && v.Elem().IsNil() ||
+				v.Interface().(isZeroer).IsZero()
+		}
+	case t.Kind() == reflect.Ptr && t.Implements(isZeroerType):
+		return func(v reflect.Value) bool {
+			return v.IsNil() || v.Interface().(isZeroer).IsZero()
+		}
+	case t.Implements(isZeroerType):
+		return func(v reflect.Value) bool {
+			return v.Interface().(isZeroer).IsZero()
+		}
+	case reflect.PtrTo(t).Implements(isZeroerType):
+		return func(v reflect.Value) bool {
+			if !v.CanAddr() {
+				// temporarily box the value, so that it can be addressed
+				v2 := reflect.New(v.Type()).Elem()
+				v2.Set(v)
+				v = v2
+			}
+			return v.Addr().Interface().(isZeroer).IsZero()
+		}
+	}
+	return reflect.Value.IsZero
+}
+
 func (e *encodeState) reflectValue(v reflect.Value, opts encOpts) {
-	valueEncoder(v)(e, v, opts)
+	valueEncoder(v, opts.registry)(e, v, opts)
 }
 
 type encOpts struct {
@@ -337,23 +611,41 @@ type encOpts struct {
 	quoted bool
 	// escapeHTML causes '<', '>', and '&' to be escaped in JSON strings.
 	escapeHTML bool
+	// sortMapKeys causes map keys to be written in sorted order.
+	sortMapKeys bool
+	// asciiOnly causes all non ASCII characters to be escaped in JSON strings.
+	asciiOnly bool
+	// floatFormat is the strconv format for floats, 0 for the default.
+	floatFormat byte
+	// nilSliceAsEmpty and nilMapAsEmpty encode nil slices and maps as empty.
+	nilSliceAsEmpty bool
+	nilMapAsEmpty   bool
+	// registry holds the custom encoders to use, if any.
+	registry *Registry
 }
 
 type encoderFunc func(e *encodeState, v reflect.Value, opts encOpts)
 
-var encoderCache struct {
+type encoderMap struct {
 	sync.RWMutex
 	m map[reflect.Type]encoderFunc
 }
 
-func valueEncoder(v reflect.Value) encoderFunc {
+// encoders for a nil registry; each Registry has its own
+var encoderCache encoderMap
+
+func valueEncoder(v reflect.Value, r *Registry) encoderFunc {
 	if !v.IsValid() {
 		return invalidValueEncoder
 	}
-	return typeEncoder(v.Type())
+	return typeEncoder(v.Type(), r)
 }
 
-func typeEncoder(t reflect.Type) encoderFunc {
+func typeEncoder(t reflect.Type, r *Registry) encoderFunc {
+	encoderCache := &encoderCache
+	if r != nil {
+		encoderCache = &r.encoderCache
+	}
 	encoderCache.RLock()
 	f := encoderCache.m[t]
 	encoderCache.RUnlock()
@@ -379,7 +671,7 @@ func typeEncoder(t reflect.Type) encoderFunc {
 
 	// Compute fields without lock.
 	// Might duplicate effort but won't hold other computations back.
-	f = newTypeEncoder(t, true)
+	f = newTypeEncoder(t, true, r)
 	wg.Done()
 	encoderCache.Lock()
 	encoderCache.m[t] = f
@@ -388,19 +680,37 @@ func typeEncoder(t reflect.Type) encoderFunc {
 }
 
 var (
-	marshalerType     = reflect.TypeOf(new(Marshaler)).Elem()
-	textMarshalerType = reflect.TypeOf(new(encoding.TextMarshaler)).Elem()
+	marshalerType        = reflect.TypeOf(new(Marshaler)).Elem()
+	marshalerContextType = reflect.TypeOf(new(MarshalerContext)).Elem()
+	textMarshalerType    = reflect.TypeOf(new(encoding.TextMarshaler)).Elem()
 )
 
-// newTypeEncoder constructs an encoderFunc for a type.
+// newTypeEncoder constructs an encoderFunc for a type, using the encoders
+// registered in r, if any.
 // The returned encoder only checks CanAddr when allowAddr is true.
-func newTypeEncoder(t reflect.Type, allowAddr bool) encoderFunc {
+func newTypeEncoder(t reflect.Type, allowAddr bool, r *Registry) encoderFunc {
+	if f := r.encoder(t); f != nil {
+		return f
+	}
+	if t.Kind() == reflect.Ptr && r.encoder(t.Elem()) != nil {
+		// the element's registered encoder takes precedence over the pointer's methods
+		return newPtrEncoder(t, r)
+	}
+	if t.Implements(marshalerContextType) {
+		return marshalerContextEncoder
+	}
+	if t.Kind() != reflect.Ptr && allowAddr {
+		if reflect.PtrTo(t).Implements(marshalerContextType) {
+			return newCondAddrEncoder(addrMarshalerContextEncoder, newTypeEncoder(t, false, r))
+		}
+	}
+
 	if t.Implements(marshalerType) {
 		return marshalerEncoder
 	}
 	if t.Kind() != reflect.Ptr && allowAddr {
 		if reflect.PtrTo(t).Implements(marshalerType) {
-			return newCondAddrEncoder(addrMarshalerEncoder, newTypeEncoder(t, false))
+			return newCondAddrEncoder(addrMarshalerEncoder, newTypeEncoder(t, false, r))
 		}
 	}
 
@@ -409,7 +719,7 @@ func newTypeEncoder(t reflect.Type, allowAddr bool) encoderFunc {
 	}
 	if t.Kind() != reflect.Ptr && allowAddr {
 		if reflect.PtrTo(t).Implements(textMarshalerType) {
-			return newCondAddrEncoder(addrTextMarshalerEncoder, newTypeEncoder(t, false))
+			return newCondAddrEncoder(addrTextMarshalerEncoder, newTypeEncoder(t, false, r))
 		}
 	}
 
@@ -429,15 +739,15 @@ func newTypeEncoder(t reflect.Type, allowAddr bool) encoderFunc {
 	case reflect.Interface:
 		return interfaceEncoder
 	case reflect.Struct:
-		return newStructEncoder(t)
+		return newStructEncoder(t, r)
 	case reflect.Map:
-		return newMapEncoder(t)
+		return newMapEncoder(t, r)
 	case reflect.Slice:
-		return newSliceEncoder(t)
+		return newSliceEncoder(t, r)
 	case reflect.Array:
-		return newArrayEncoder(t)
+		return newArrayEncoder(t, r)
 	case reflect.Ptr:
-		return newPtrEncoder(t)
+		return newPtrEncoder(t, r)
 	default:
 		return unsupportedTypeEncoder
 	}
@@ -459,7 +769,48 @@ func marshalerEncoder(e *encodeState, v reflect.Value, opts encOpts) {
 		err = compact(&e.Buffer, b, opts.escapeHTML)
 	}
 	if err != nil {
-		e.error(&MarshalerError{v.Type(), err})
+		e.error(&MarshalerError{Type: v.Type(), Err: err})
+	}
+}
+
+// context returns the context for MarshalerContext values
+func (e *encodeState) context() context.Context {
+	if e.ctx == nil {
+		return context.Background()
+	}
+	return e.ctx
+}
+
+func marshalerContextEncoder(e *encodeState, v reflect.Value, opts encOpts) {
+	if v.Kind() == reflect.Ptr && v.IsNil() {
+		e.WriteString("null")
+		return
+	}
+	m := v.Interface().(MarshalerContext)
+	b, err := m.MarshalJSONContext(e.context())
+	if err == nil {
+		// copy JSON into buffer, checking validity.
+		err = compact(&e.Buffer, b, opts.escapeHTML)
+	}
+	if err != nil {
+		e.error(&MarshalerError{Type: v.Type(), Err: err})
+	}
+}
+
+func addrMarshalerContextEncoder(e *encodeState, v reflect.Value, opts encOpts) {
+	va := v.Addr()
+	if va.IsNil() {
+		e.WriteString("null")
+		return
+	}
+	m := va.Interface().(MarshalerContext)
+	b, err := m.MarshalJSONContext(e.context())
+	if err == nil {
+		// copy JSON into buffer, checking validity.
+		err = compact(&e.Buffer, b, opts.escapeHTML)
+	}
+	if err != nil {
+		e.error(&MarshalerError{Type: v.Type(), Err: err})
 	}
 }
 
@@ -476,7 +827,7 @@ func addrMarshalerEncoder(e *encodeState, v reflect.Value, _ encOpts) {
 		err = compact(&e.Buffer, b, true)
 	}
 	if err != nil {
-		e.error(&MarshalerError{v.Type(), err})
+		e.error(&MarshalerError{Type: v.Type(), Err: err})
 	}
 }
 
@@ -488,9 +839,9 @@ func textMarshalerEncoder(e *encodeState, v reflect.Value, opts encOpts) {
 	m := v.Interface().(encoding.TextMarshaler)
 	b, err := m.MarshalText()
 	if err != nil {
-		e.error(&MarshalerError{v.Type(), err})
+		e.error(&MarshalerError{Type: v.Type(), Err: err})
 	}
-	e.stringBytes(b, opts.escapeHTML)
+	e.stringBytes(b, opts)
 }
 
 func addrTextMarshalerEncoder(e *encodeState, v reflect.Value, opts encOpts) {
@@ -502,9 +853,9 @@ func addrTextMarshalerEncoder(e *encodeState, v reflect.Value, opts encOpts) {
 	m := va.Interface().(encoding.TextMarshaler)
 	b, err := m.MarshalText()
 	if err != nil {
-		e.error(&MarshalerError{v.Type(), err})
+		e.error(&MarshalerError{Type: v.Type(), Err: err})
 	}
-	e.stringBytes(b, opts.escapeHTML)
+	e.stringBytes(b, opts)
 }
 
 func boolEncoder(e *encodeState, v reflect.Value, opts encOpts) {
@@ -550,7 +901,11 @@ func (bits floatEncoder) encode(e *encodeState, v reflect.Value, opts encOpts) {
 	if math.IsInf(f, 0) || math.IsNaN(f) {
 		e.error(&UnsupportedValueError{v, strconv.FormatFloat(f, 'g', -1, int(bits))})
 	}
-	b := strconv.AppendFloat(e.scratch[:0], f, 'g', -1, int(bits))
+	format := opts.floatFormat
+	if format == 0 {
+		format = 'g'
+	}
+	b := strconv.AppendFloat(e.scratch[:0], f, format, -1, int(bits))
 	if opts.quoted {
 		e.WriteByte('"')
 	}
@@ -584,9 +939,9 @@ func stringEncoder(e *encodeState, v reflect.Value, opts encOpts) {
 		if err != nil {
 			e.error(err)
 		}
-		e.string(string(sb), opts.escapeHTML)
+		e.string(string(sb), opts)
 	} else {
-		e.string(v.String(), opts.escapeHTML)
+		e.string(v.String(), opts)
 	}
 }
 
@@ -605,6 +960,11 @@ func unsupportedTypeEncoder(e *encodeState, v reflect.Value, _ encOpts) {
 type structEncoder struct {
 	fields    []field
 	fieldEncs []encoderFunc
+
+	// the inline map, if any
+	nameIndex     map[string]int
+	inlineMap     []int
+	inlineElemEnc encoderFunc
 }
 
 func (se *structEncoder) encode(e *encodeState, v reflect.Value, opts encOpts) {
@@ -612,7 +972,7 @@ func (se *structEncoder) encode(e *encodeState, v reflect.Value, opts encOpts) {
 	first := true
 	for i, f := range se.fields {
 		fv := fieldByIndex(v, f.index)
-		if !fv.IsValid() || f.omitEmpty && isEmptyValue(fv) {
+		if !fv.IsValid() || f.omitEmpty && isEmptyValue(fv) || f.isZero != nil && f.isZero(fv) {
 			continue
 		}
 		if first {
@@ -620,44 +980,128 @@ func (se *structEncoder) encode(e *encodeState, v reflect.Value, opts encOpts) {
 		} else {
 			e.WriteByte(',')
 		}
-		e.string(f.name, opts.escapeHTML)
+		e.string(f.name, opts)
 		e.WriteByte(':')
 		opts.quoted = f.quoted
 		se.fieldEncs[i](e, fv, opts)
 	}
+	if se.inlineMap != nil {
+		m := fieldByIndex(v, se.inlineMap)
+		if m.IsValid() && m.Len() > 0 {
+			se.encodeInlineMap(e, m, first, opts)
+		}
+	}
 	e.WriteByte('}')
 }
 
-func newStructEncoder(t reflect.Type) encoderFunc {
-	fields := cachedTypeFields(t)
+// encodeInlineMap encodes the entries of the inline map m as members of
+// the enclosing object, skipping those whose key is the name of a field
+func (se *structEncoder) encodeInlineMap(e *encodeState, m reflect.Value, first bool, opts encOpts) {
+	keys := m.MapKeys()
+	if opts.sortMapKeys {
+		sort.Slice(keys, func(i, j int) bool {
+			return keys[i].String() < keys[j].String()
+		})
+	}
+	for _, k := range keys {
+		if _, ok := se.nameIndex[k.String()]; ok {
+			continue
+		}
+		if first {
+			first = false
+		} else {
+			e.WriteByte(',')
+		}
+		e.string(k.String(), opts)
+		e.WriteByte(':')
+		se.inlineElemEnc(e, m.MapIndex(k), opts)
+	}
+}
+
+func newStructEncoder(t reflect.Type, r *Registry) encoderFunc {
+	sf := cachedTypeFields(t)
+	fields := sf.list
 	se := &structEncoder{
 		fields:    fields,
 		fieldEncs: make([]encoderFunc, len(fields)),
 	}
 	for i, f := range fields {
-		se.fieldEncs[i] = typeEncoder(typeByIndex(t, f.index))
+		se.fieldEncs[i] = typeEncoder(typeByIndex(t, f.index), r)
+	}
+	if sf.inlineMap != nil {
+		se.nameIndex = sf.nameIndex
+		se.inlineMap = sf.inlineMap
+		se.inlineElemEnc = typeEncoder(typeByIndex(t, sf.inlineMap).Elem(), r)
 	}
 	return se.encode
 }
 
 type mapEncoder struct {
 	elemEnc encoderFunc
+
+	// the type is map[string]interface{}
+	stringInterface bool
 }
 
+var mapStringInterfaceType = reflect.TypeOf(map[string]interface{}(nil))
+
 func (me *mapEncoder) encode(e *encodeState, v reflect.Value, opts encOpts) {
 	if v.IsNil() {
-		e.WriteString("null")
+		if opts.nilMapAsEmpty {
+			e.WriteString("{}")
+		} else {
+			e.WriteString("null")
+		}
 		return
 	}
+	if e.ptrLevel++; e.ptrLevel > startDetectingCyclesAfter {
+		// We're a large number of nested maps, slices and pointers deep;
+		// start checking if this map is already being encoded.
+		key := ptrKey{ptr: v.Pointer(), typ: v.Type()}
+		e.markSeen(v, key)
+		defer delete(e.ptrSeen, key)
+	}
+	if me.stringInterface && v.CanInterface() {
+		encodeStringInterfaceMap(e, v.Interface().(map[string]interface{}), opts)
+	} else {
+		me.encodeEntries(e, v, opts)
+	}
+	e.ptrLevel--
+}
+
+// encodeEntries encodes a non nil map of any type
+func (me *mapEncoder) encodeEntries(e *encodeState, v reflect.Value, opts encOpts) {
 	e.WriteByte('{')
 
+	// keys in map order
+	if !opts.sortMapKeys {
+		first := true
+		iter := v.MapRange()
+		for iter.Next() {
+			if first {
+				first = false
+			} else {
+				e.WriteByte(',')
+			}
+			kv := reflectWithString{v: iter.Key()}
+			if err := kv.resolve(); err != nil {
+				e.error(&MarshalerError{Type: kv.v.Type(), Err: err})
+			}
+			e.string(kv.s, opts)
+			e.WriteByte(':')
+			me.elemEnc(e, iter.Value(), opts)
+		}
+		e.WriteByte('}')
+		return
+	}
+
 	// Extract and sort the keys.
 	keys := v.MapKeys()
 	sv := make([]reflectWithString, len(keys))
 	for i, v := range keys {
 		sv[i].v = v
 		if err := sv[i].resolve(); err != nil {
-			e.error(&MarshalerError{v.Type(), err})
+			e.error(&MarshalerError{Type: v.Type(), Err: err})
 		}
 	}
 	sort.Sort(byString(sv))
@@ -666,14 +1110,14 @@ func (me *mapEncoder) encode(e *encodeState, v reflect.Value, opts encOpts) {
 		if i > 0 {
 			e.WriteByte(',')
 		}
-		e.string(kv.s, opts.escapeHTML)
+		e.string(kv.s, opts)
 		e.WriteByte(':')
 		me.elemEnc(e, v.MapIndex(kv.v), opts)
 	}
 	e.WriteByte('}')
 }
 
-func newMapEncoder(t reflect.Type) encoderFunc {
+func newMapEncoder(t reflect.Type, r *Registry) encoderFunc {
 	switch t.Key().Kind() {
 	case reflect.String,
 		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
@@ -683,15 +1127,62 @@ func newMapEncoder(t reflect.Type) encoderFunc {
 			return unsupportedTypeEncoder
 		}
 	}
-	me := &mapEncoder{typeEncoder(t.Elem())}
+	me := &mapEncoder{elemEnc: typeEncoder(t.Elem(), r), stringInterface: t == mapStringInterfaceType}
 	return me.encode
 }
 
-func encodeByteSlice(e *encodeState, v reflect.Value, _ encOpts) {
-	if v.IsNil() {
+// encodeStringInterfaceMap encodes the most common map type
+// without resolving keys and values through reflection
+func encodeStringInterfaceMap(e *encodeState, m map[string]interface{}, opts encOpts) {
+	e.WriteByte('{')
+	if opts.sortMapKeys {
+		keys := make([]string, 0, len(m))
+		for k := range m {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for i, k := range keys {
+			if i > 0 {
+				e.WriteByte(',')
+			}
+			e.string(k, opts)
+			e.WriteByte(':')
+			e.interfaceValue(m[k], opts)
+		}
+	} else {
+		first := true
+		for k, val := range m {
+			if first {
+				first = false
+			} else {
+				e.WriteByte(',')
+			}
+			e.string(k, opts)
+			e.WriteByte(':')
+			e.interfaceValue(val, opts)
+		}
+	}
+	e.WriteByte('}')
+}
+
+// interfaceValue encodes a value held in an interface, as interfaceEncoder does
+func (e *encodeState) interfaceValue(val interface{}, opts encOpts) {
+	if val == nil {
 		e.WriteString("null")
 		return
 	}
+	e.reflectValue(reflect.ValueOf(val), opts)
+}
+
+func encodeByteSlice(e *encodeState, v reflect.Value, opts encOpts) {
+	if v.IsNil() {
+		if opts.nilSliceAsEmpty {
+			e.WriteString(`""`)
+		} else {
+			e.WriteString("null")
+		}
+		return
+	}
 	s := v.Bytes()
 	e.WriteByte('"')
 	if len(s) < 1024 {
@@ -716,13 +1207,25 @@ type sliceEncoder struct {
 
 func (se *sliceEncoder) encode(e *encodeState, v reflect.Value, opts encOpts) {
 	if v.IsNil() {
-		e.WriteString("null")
+		if opts.nilSliceAsEmpty {
+			e.WriteString("[]")
+		} else {
+			e.WriteString("null")
+		}
 		return
 	}
+	if e.ptrLevel++; e.ptrLevel > startDetectingCyclesAfter {
+		// We're a large number of nested maps, slices and pointers deep;
+		// start checking if this slice is already being encoded.
+		key := ptrKey{ptr: v.Pointer(), len: v.Len(), typ: v.Type()}
+		e.markSeen(v, key)
+		defer delete(e.ptrSeen, key)
+	}
 	se.arrayEnc(e, v, opts)
+	e.ptrLevel--
 }
 
-func newSliceEncoder(t reflect.Type) encoderFunc {
+func newSliceEncoder(t reflect.Type, r *Registry) encoderFunc {
 	// Byte slices get special treatment; arrays don't.
 	if t.Elem().Kind() == reflect.Uint8 {
 		p := reflect.PtrTo(t.Elem())
@@ -730,7 +1233,7 @@ func newSliceEncoder(t reflect.Type) encoderFunc {
 			return encodeByteSlice
 		}
 	}
-	enc := &sliceEncoder{newArrayEncoder(t)}
+	enc := &sliceEncoder{newArrayEncoder(t, r)}
 	return enc.encode
 }
 
@@ -750,8 +1253,8 @@ func (ae *arrayEncoder) encode(e *encodeState, v reflect.Value, opts encOpts) {
 	e.WriteByte(']')
 }
 
-func newArrayEncoder(t reflect.Type) encoderFunc {
-	enc := &arrayEncoder{typeEncoder(t.Elem())}
+func newArrayEncoder(t reflect.Type, r *Registry) encoderFunc {
+	enc := &arrayEncoder{typeEncoder(t.Elem(), r)}
 	return enc.encode
 }
 
@@ -764,11 +1267,19 @@ func (pe *ptrEncoder) encode(e *encodeState, v reflect.Value, opts encOpts) {
 		e.WriteString("null")
 		return
 	}
+	if e.ptrLevel++; e.ptrLevel > startDetectingCyclesAfter {
+		// We're a large number of nested ptrEncoder.encode calls deep;
+		// start checking if we've run into a pointer cycle.
+		key := ptrKey{ptr: v.Pointer(), typ: v.Type()}
+		e.markSeen(v, key)
+		defer delete(e.ptrSeen, key)
+	}
 	pe.elemEnc(e, v.Elem(), opts)
+	e.ptrLevel--
 }
 
-func newPtrEncoder(t reflect.Type) encoderFunc {
-	enc := &ptrEncoder{typeEncoder(t.Elem())}
+func newPtrEncoder(t reflect.Type, r *Registry) encoderFunc {
+	enc := &ptrEncoder{typeEncoder(t.Elem(), r)}
 	return enc.encode
 }
 
@@ -869,14 +1380,14 @@ func (sv byString) Swap(i, j int)      { sv[i], sv[j] = sv[j], sv[i] }
 func (sv byString) Less(i, j int) bool { return sv[i].s < sv[j].s }
 
 // NOTE: keep in sync with stringBytes below.
-func (e *encodeState) string(s string, escapeHTML bool) int {
+func (e *encodeState) string(s string, opts encOpts) int {
 	len0 := e.Len()
 	e.WriteByte('"')
 	start := 0
 	for i := 0; i < len(s); {
 		if b := s[i]; b < utf8.RuneSelf {
 			if 0x20 <= b && b != '\\' && b != '"' &&
-				(!escapeHTML || b != '<' && b != '>' && b != '&') {
+				(!opts.escapeHTML || b != '<' && b != '>' && b != '&') {
 				i++
 				continue
 			}
@@ -920,6 +1431,15 @@ func (e *encodeState) string(s string, escapeHTML bool) int {
 			start = i
 			continue
 		}
+		if opts.asciiOnly {
+			if start < i {
+				e.WriteString(s[start:i])
+			}
+			e.runeEscape(c)
+			i += size
+			start = i
+			continue
+		}
 		// U+2028 is LINE SEPARATOR.
 		// U+2029 is PARAGRAPH SEPARATOR.
 		// They are both technically valid characters in JSON strings,
@@ -947,14 +1467,14 @@ func (e *encodeState) string(s string, escapeHTML bool) int {
 }
 
 // NOTE: keep in sync with string above.
-func (e *encodeState) stringBytes(s []byte, escapeHTML bool) int {
+func (e *encodeState) stringBytes(s []byte, opts encOpts) int {
 	len0 := e.Len()
 	e.WriteByte('"')
 	start := 0
 	for i := 0; i < len(s); {
 		if b := s[i]; b < utf8.RuneSelf {
 			if 0x20 <= b && b != '\\' && b != '"' &&
-				(!escapeHTML || b != '<' && b != '>' && b != '&') {
+				(!opts.escapeHTML || b != '<' && b != '>' && b != '&') {
 				i++
 				continue
 			}
@@ -998,6 +1518,15 @@ func (e *encodeState) stringBytes(s []byte, escapeHTML bool) int {
 			start = i
 			continue
 		}
+		if opts.asciiOnly {
+			if start < i {
+				e.Write(s[start:i])
+			}
+			e.runeEscape(c)
+			i += size
+			start = i
+			continue
+		}
 		// U+2028 is LINE SEPARATOR.
 		// U+2029 is PARAGRAPH SEPARATOR.
 		// They are both technically valid characters in JSON strings,
@@ -1024,6 +1553,20 @@ func (e *encodeState) stringBytes(s []byte, escapeHTML bool) int {
 	return e.Len() - len0
 }
 
+// runeEscape writes r as a \u escape, or a surrogate pair of them
+func (e *encodeState) runeEscape(r rune) {
+	if r >= 0x10000 {
+		r1, r2 := utf16.EncodeRune(r)
+		e.runeEscape(r1)
+		r = r2
+	}
+	e.WriteString(`\u`)
+	e.WriteByte(hex[r>>12&0xF])
+	e.WriteByte(hex[r>>8&0xF])
+	e.WriteByte(hex[r>>4&0xF])
+	e.WriteByte(hex[r&0xF])
+}
+
 // A field represents a single field found in a struct.
 type field struct {
 	name      string
@@ -1035,6 +1578,13 @@ type field struct {
 	typ       reflect.Type
 	omitEmpty bool
 	quoted    bool
+	goPath    string                   // dotted Go names of the field and the embedded structs holding it
+	isZero    func(reflect.Value) bool // set for the "omitzero" option
+
+	required     bool
+	hasDefault   bool
+	defaultValue []byte // JSON text stored in the field when absent
+	defaultErr   error  // reported instead, if the default is not valid JSON
 }
 
 func fillField(f field) field {
@@ -1084,10 +1634,11 @@ func (x byIndex) Less(i, j int) bool {
 	return len(x[i].index) < len(x[j].index)
 }
 
-// typeFields returns a list of fields that JSON should recognize for the given type.
+// typeFields returns a list of fields that JSON should recognize for the given type,
+// and the index sequence of the map field tagged inline, if any.
 // The algorithm is breadth-first search over the set of structs to include - the top struct
-// and then any reachable anonymous structs.
-func typeFields(t reflect.Type) []field {
+// and then any reachable anonymous or inline structs.
+func typeFields(t reflect.Type) ([]field, []int) {
 	// Anonymous fields to explore at the current level and the next.
 	current := []field{}
 	next := []field{{typ: t}}
@@ -1102,6 +1653,9 @@ func typeFields(t reflect.Type) []field {
 	// Fields found.
 	var fields []field
 
+	// Inline maps found, shallowest first.
+	var inlineMaps [][]int
+
 	for len(next) > 0 {
 		current, next = next, current[:0]
 		count, nextCount = nextCount, map[reflect.Type]int{}
@@ -1129,6 +1683,10 @@ func typeFields(t reflect.Type) []field {
 				index := make([]int, len(f.index)+1)
 				copy(index, f.index)
 				index[len(f.index)] = i
+				goPath := sf.Name
+				if f.goPath != "" {
+					goPath = f.goPath + "." + sf.Name
+				}
 
 				ft := sf.Type
 				if ft.Name() == "" && ft.Kind() == reflect.Ptr {
@@ -1149,20 +1707,63 @@ func typeFields(t reflect.Type) []field {
 					}
 				}
 
+				// Inline structs are explored as anonymous ones are,
+				// inline maps collect the keys no field matches.
+				inline := false
+				if opts.Contains("inline") {
+					switch {
+					case ft.Kind() == reflect.Struct:
+						inline = true
+					case sf.Type.Kind() == reflect.Map && sf.Type.Key().Kind() == reflect.String:
+						inlineMaps = append(inlineMaps, index)
+						if count[f.typ] > 1 {
+							inlineMaps = append(inlineMaps, index)
+						}
+						continue
+					}
+				}
+
 				// Record found field and index sequence.
-				if name != "" || !sf.Anonymous || ft.Kind() != reflect.Struct {
+				if !inline && (name != "" || !sf.Anonymous || ft.Kind() != reflect.Struct) {
 					tagged := name != ""
 					if name == "" {
 						name = sf.Name
 					}
-					fields = append(fields, fillField(field{
+					fld := field{
 						name:      name,
 						tag:       tagged,
 						index:     index,
 						typ:       ft,
 						omitEmpty: opts.Contains("omitempty"),
 						quoted:    quoted,
-					}))
+						goPath:    goPath,
+						required:  opts.Contains("required"),
+					}
+					if opts.Contains("omitzero") {
+						fld.isZero = zeroFunc(sf.Type)
+					}
+					if def, ok := opts.Default(); ok {
+						fld.hasDefault = true
+						dt := sf.Type
+						for dt.Kind() == reflect.Ptr {
+							dt = dt.Elem()
+						}
+
+						// string defaults are given unquoted, other defaults are
+						// validated once here, rather than on every decode
+						if dt.Kind() == reflect.String {
+							fld.defaultValue, _ = Marshal(def)
+						} else {
+							var scan scanner
+
+							fld.defaultValue = []byte(def)
+							setScanner(&scan, fld.defaultValue)
+							if err := checkValid(fld.defaultValue, &scan); err != nil {
+								fld.defaultErr = fmt.Errorf("json: invalid default for field %s in struct %v: %v", name, f.typ, err)
+							}
+						}
+					}
+					fields = append(fields, fillField(fld))
 					if count[f.typ] > 1 {
 						// If there were multiple instances, add a second,
 						// so that the annihilation code will see a duplicate.
@@ -1176,7 +1777,7 @@ func typeFields(t reflect.Type) []field {
 				// Record new anonymous struct to explore in next round.
 				nextCount[ft]++
 				if nextCount[ft] == 1 {
-					next = append(next, fillField(field{name: ft.Name(), index: index, typ: ft}))
+					next = append(next, fillField(field{name: ft.Name(), index: index, typ: ft, goPath: goPath}))
 				}
 			}
 		}
@@ -1215,7 +1816,12 @@ func typeFields(t reflect.Type) []field {
 	fields = out
 	sort.Sort(byIndex(fields))
 
-	return fields
+	// As with fields, the shallowest inline map wins, and several at
+	// the same depth cancel each other out.
+	if len(inlineMaps) == 1 || len(inlineMaps) > 1 && len(inlineMaps[0]) < len(inlineMaps[1]) {
+		return fields, inlineMaps[0]
+	}
+	return fields, nil
 }
 
 // dominantField looks through the fields, all of which are known to
@@ -1256,29 +1862,44 @@ func dominantField(fields []field) (field, bool) {
 	return fields[0], true
 }
 
+// structFields holds the fields of a struct type, and their index by exact name.
+type structFields struct {
+	list      []field
+	nameIndex map[string]int
+	inlineMap []int // index sequence of the inline map field, or nil
+
+	// some field is required or has a default, so the decoder
+	// needs to know which fields an object contains
+	checkPresence bool
+}
+
 var fieldCache struct {
-	value atomic.Value // map[reflect.Type][]field
+	value atomic.Value // map[reflect.Type]structFields
 	mu    sync.Mutex   // used only by writers
 }
 
 // cachedTypeFields is like typeFields but uses a cache to avoid repeated work.
-func cachedTypeFields(t reflect.Type) []field {
-	m, _ := fieldCache.value.Load().(map[reflect.Type][]field)
-	f := m[t]
-	if f != nil {
+func cachedTypeFields(t reflect.Type) structFields {
+	m, _ := fieldCache.value.Load().(map[reflect.Type]structFields)
+	f, ok := m[t]
+	if ok {
 		return f
 	}
 
 	// Compute fields without lock.
 	// Might duplicate effort but won't hold other computations back.
-	f = typeFields(t)
-	if f == nil {
-		f = []field{}
+	f.list, f.inlineMap = typeFields(t)
+	f.nameIndex = make(map[string]int, len(f.list))
+	for i := range f.list {
+		f.nameIndex[f.list[i].name] = i
+		if f.list[i].required || f.list[i].hasDefault {
+			f.checkPresence = true
+		}
 	}
 
 	fieldCache.mu.Lock()
-	m, _ = fieldCache.value.Load().(map[reflect.Type][]field)
-	newM := make(map[reflect.Type][]field, len(m)+1)
+	m, _ = fieldCache.value.Load().(map[reflect.Type]structFields)
+	newM := make(map[reflect.Type]structFields, len(m)+1)
 	for k, v := range m {
 		newM[k] = v
 	}
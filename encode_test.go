@@ -6,10 +6,15 @@ package json
 
 import (
 	"bytes"
+	"context"
+	"errors"
 	"fmt"
 	"math"
 	"reflect"
+	"strconv"
+	"strings"
 	"testing"
+	"time"
 	"unicode"
 )
 
@@ -67,6 +72,225 @@ func TestOmitEmpty(t *testing.T) {
 	}
 }
 
+type zeroPtrRecv struct {
+	N int
+}
+
+func (z *zeroPtrRecv) IsZero() bool { return z.N < 0 }
+
+type zeroNever struct{}
+
+func (zeroNever) IsZero() bool { return false }
+
+type OmitZeros struct {
+	T   time.Time       `json:"t,omitzero"`
+	TP  *time.Time      `json:"tp,omitzero"`
+	S   struct{ A int } `json:"s,omitzero"`
+	E   struct{ A int } `json:"e,omitempty"`
+	P   zeroPtrRecv     `json:"p,omitzero"`
+	N   zeroNever       `json:"n,omitzero"`
+	I   isZeroer        `json:"i,omitzero"`
+	A   [2]int          `json:"a,omitzero"`
+	F   float64         `json:"f,omitzero"`
+	Str string          `json:"str,omitempty,omitzero"`
+}
+
+func TestOmitZero(t *testing.T) {
+	var o OmitZeros
+	o.P.N = -1
+	for _, v := range []interface{}{o, &o} {
+		got, err := Marshal(v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := `{"e":{"A":0},"n":{}}`; string(got) != want {
+			t.Errorf("%T: expected %s got %s", v, want, got)
+		}
+	}
+
+	var nilTime *time.Time
+	o = OmitZeros{
+		T:  time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC),
+		TP: new(time.Time),
+		I:  nilTime,
+		A:  [2]int{0, 1},
+	}
+	o.S.A = 1
+	got, err := Marshal(o)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"t":"2026-01-02T03:04:05Z","s":{"A":1},"e":{"A":0},"p":{"N":0},"n":{},"a":[0,1]}`
+	if string(got) != want {
+		t.Errorf("expected %s got %s", want, got)
+	}
+}
+
+func TestMarshalerErrorPath(t *testing.T) {
+	type Inner struct {
+		Items []interface{} `json:"items"`
+	}
+	type Outer struct {
+		Inner Inner                  `json:"inner"`
+		Map   map[string]interface{} `json:"map"`
+		Keys  map[failKey]int        `json:"keys"`
+	}
+
+	errFail := errors.New("fail")
+	for _, tt := range []struct {
+		v       interface{}
+		pointer string
+		field   string
+	}{
+		{Outer{Inner: Inner{Items: []interface{}{1, failMarshaler{errFail}}}}, "/inner/items/1", "Inner.Items"},
+		{Outer{Map: map[string]interface{}{"a~b": failMarshaler{errFail}}}, "/map/a~0b", "Map"},
+		{failMarshaler{errFail}, "", ""},
+		{Outer{Inner: Inner{Items: []interface{}{&Inner{Items: []interface{}{failMarshaler{errFail}}}}}}, "/inner/items/0/items/0", "Inner.Items.Items"},
+		{Outer{Map: map[string]interface{}{"a": []byte("<"), "b": map[int]interface{}{2: failMarshaler{errFail}}}}, "/map/b/2", "Map"},
+		{Outer{Keys: map[failKey]int{{"a", nil}: 1, {"b", nil}: 2, {"c", errFail}: 3, {"d", nil}: 4}}, "/keys", "Keys"},
+	} {
+		_, err := Marshal(tt.v)
+		me, ok := err.(*MarshalerError)
+		if !ok {
+			t.Errorf("%#v: expected MarshalerError, got %v", tt.v, err)
+			continue
+		}
+		if me.Pointer != tt.pointer || me.Field != tt.field {
+			t.Errorf("%#v: expected %q %q, got %q %q", tt.v, tt.pointer, tt.field, me.Pointer, me.Field)
+		}
+		if !errors.Is(err, errFail) {
+			t.Errorf("%#v: expected error to wrap %v", tt.v, errFail)
+		}
+	}
+
+	// invalid output is dropped before the path is worked out
+	_, err := Marshal(map[string][]interface{}{"a": {1, rawMarshaler(`{"b": [2, `)}})
+	if me, ok := err.(*MarshalerError); !ok || me.Pointer != "/a/1" {
+		t.Errorf("expected MarshalerError at /a/1, got %v", err)
+	}
+
+	// output already in the buffer is not part of the value
+	type Failing struct {
+		B failMarshaler
+	}
+	for _, prefix := range []string{"", `{"a":[1,`, "]]]", `"x`} {
+		buf := bytes.NewBufferString(prefix)
+		err := MarshalNoEscapeToBuffer(Failing{failMarshaler{errFail}}, buf)
+		if me, ok := err.(*MarshalerError); !ok || me.Pointer != "/B" || me.Field != "B" {
+			t.Errorf("%q: expected MarshalerError at /B, got %v", prefix, err)
+		}
+	}
+}
+
+type failMarshaler struct {
+	err error
+}
+
+func (f failMarshaler) MarshalJSON() ([]byte, error) {
+	return nil, f.err
+}
+
+// failKey fails to encode as a map key if err is set
+type failKey struct {
+	s   string
+	err error
+}
+
+func (k failKey) MarshalText() ([]byte, error) {
+	if k.err != nil {
+		return nil, k.err
+	}
+	return []byte(k.s), nil
+}
+
+// rawMarshaler encodes as its own text, which may not be valid JSON
+type rawMarshaler string
+
+func (r rawMarshaler) MarshalJSON() ([]byte, error) {
+	return []byte(r), nil
+}
+
+// panicMarshaler panics with its value
+type panicMarshaler struct {
+	v interface{}
+}
+
+func (p panicMarshaler) MarshalJSON() ([]byte, error) {
+	panic(p.v)
+}
+
+func TestMarshalerPanic(t *testing.T) {
+	// panics other than errors go through the encoders untouched
+	p := "boom"
+	defer func() {
+		if r := recover(); r != p {
+			t.Errorf("expected %v, got %v", p, r)
+		}
+	}()
+	Marshal(map[string]interface{}{"a": []interface{}{struct{ P panicMarshaler }{panicMarshaler{p}}}})
+	t.Error("expected panic")
+}
+
+type versionKey struct{}
+
+// versioned encodes as a string before version 2 and as a number after
+type versioned int
+
+func (v versioned) MarshalJSONContext(ctx context.Context) ([]byte, error) {
+	if version, _ := ctx.Value(versionKey{}).(int); version < 2 {
+		return []byte(strconv.Quote(strconv.Itoa(int(v)))), nil
+	}
+	return []byte(strconv.Itoa(int(v))), nil
+}
+
+func (v versioned) MarshalJSON() ([]byte, error) {
+	return nil, errors.New("MarshalJSON called")
+}
+
+type versionedPtr struct {
+	N int
+}
+
+func (v *versionedPtr) MarshalJSONContext(ctx context.Context) ([]byte, error) {
+	return []byte(fmt.Sprintf(`{"n%v":%d}`, ctx.Value(versionKey{}), v.N)), nil
+}
+
+func TestMarshalContext(t *testing.T) {
+	v := struct {
+		A versioned
+		B []versioned
+		C versionedPtr
+	}{1, []versioned{2}, versionedPtr{3}}
+
+	for _, tt := range []struct {
+		ctx  context.Context
+		want string
+	}{
+		{nil, `{"A":"1","B":["2"],"C":{"N":3}}`},
+		{context.WithValue(context.Background(), versionKey{}, 2), `{"A":1,"B":[2],"C":{"N":3}}`},
+	} {
+		got, err := MarshalContext(tt.ctx, v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != tt.want {
+			t.Errorf("expected %s got %s", tt.want, got)
+		}
+	}
+
+	// addressable values use pointer methods
+	ctx := context.WithValue(context.Background(), versionKey{}, 2)
+	var buf bytes.Buffer
+	enc := NewEncoder(&buf)
+	enc.SetContext(ctx)
+	if err := enc.Encode(&v); err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"A":1,"B":[2],"C":{"n2":3}}` + "\n"; buf.String() != want {
+		t.Errorf("expected %s got %s", want, buf.String())
+	}
+}
+
 type StringTag struct {
 	BoolStr bool   `json:",string"`
 	IntStr  int64  `json:",string"`
@@ -382,12 +606,12 @@ func TestStringBytes(t *testing.T) {
 	}
 	s := string(r) + "\xff\xff\xffhello" // some invalid UTF-8 too
 
-	for _, escapeHTML := range []bool{true, false} {
+	for _, opts := range []encOpts{{escapeHTML: true}, {escapeHTML: false}, {asciiOnly: true}} {
 		es := &encodeState{}
-		es.string(s, escapeHTML)
+		es.string(s, opts)
 
 		esBytes := &encodeState{}
-		esBytes.stringBytes([]byte(s), escapeHTML)
+		esBytes.stringBytes([]byte(s), opts)
 
 		enc := es.Buffer.String()
 		encBytes := esBytes.Buffer.String()
@@ -412,8 +636,8 @@ func TestStringBytes(t *testing.T) {
 				encBytes = encBytes[:20] + "..."
 			}
 
-			t.Errorf("with escapeHTML=%t, encodings differ at %#q vs %#q",
-				escapeHTML, enc, encBytes)
+			t.Errorf("with %+v, encodings differ at %#q vs %#q",
+				opts, enc, encBytes)
 		}
 	}
 }
@@ -611,3 +835,175 @@ func TestTextMarshalerMapKeysAreSorted(t *testing.T) {
 		t.Errorf("Marshal map with text.Marshaler keys: got %#q, want %#q", b, want)
 	}
 }
+
+func TestMarshalWithOptions(t *testing.T) {
+	type S struct {
+		F  float64
+		S  []int
+		B  []byte
+		M  map[string]int
+		St string
+	}
+	v := S{F: 1e21, St: "<è😀>"}
+
+	opts := DefaultEncodeOptions()
+	b, err := MarshalWithOptions(v, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want, _ := Marshal(v); string(b) != string(want) {
+		t.Errorf("default options: got %#q, want %#q", b, want)
+	}
+
+	opts = EncodeOptions{ASCIIOnly: true, FloatFormat: 'f', NilSliceAsEmpty: true, NilMapAsEmpty: true}
+	b, err = MarshalWithOptions(v, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"F":1000000000000000000000,"S":[],"B":"","M":{},"St":"<\u00e8\ud83d\ude00>"}`
+	if string(b) != want {
+		t.Errorf("got %#q, want %#q", b, want)
+	}
+	var back S
+	if err := Unmarshal(b, &back); err != nil || back.St != v.St {
+		t.Errorf("ASCII only round trip got %q, %v", back.St, err)
+	}
+
+	// a nil []byte is encoded like an empty one, as a string
+	b, err = MarshalWithOptions([]byte(nil), opts)
+	if err != nil || string(b) != `""` {
+		t.Errorf("nil []byte got %#q, %v", b, err)
+	}
+	var bytesBack []byte
+	if err := Unmarshal(b, &bytesBack); err != nil || bytesBack == nil || len(bytesBack) != 0 {
+		t.Errorf("nil []byte round trip got %v, %v", bytesBack, err)
+	}
+
+	opts = EncodeOptions{Indent: "\t"}
+	b, err = MarshalWithOptions([]int{1}, opts)
+	if err != nil || string(b) != "[\n\t1\n]" {
+		t.Errorf("indent got %#q, %v", b, err)
+	}
+
+	// map iteration order, but the same content
+	m := map[string]int{}
+	for i := 0; i < 100; i++ {
+		m[strconv.Itoa(i)] = i
+	}
+	b, err = MarshalWithOptions(m, EncodeOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var mBack map[string]int
+	if err := Unmarshal(b, &mBack); err != nil || !reflect.DeepEqual(m, mBack) {
+		t.Errorf("unsorted map round trip got %v, %v", mBack, err)
+	}
+
+	if _, err = MarshalWithOptions(1.5, EncodeOptions{FloatFormat: 'x'}); err == nil {
+		t.Errorf("invalid float format accepted")
+	}
+
+	var buf bytes.Buffer
+	enc := NewEncoder(&buf)
+	enc.SetOptions(EncodeOptions{SortMapKeys: true, NilSliceAsEmpty: true})
+	if err := enc.Encode(map[string][]int{"b": nil, "a": {1}}); err != nil {
+		t.Fatal(err)
+	}
+	if buf.String() != "{\"a\":[1],\"b\":[]}\n" {
+		t.Errorf("Encoder with options got %#q", buf.String())
+	}
+}
+
+func TestMarshalStringInterfaceMap(t *testing.T) {
+	type namedMap map[string]interface{}
+
+	m := map[string]interface{}{
+		"b": []interface{}{1, "x", nil},
+		"a": map[string]interface{}{"z": nil, "y": 2.5, "<": "&"},
+		"c": nil,
+	}
+	for _, opts := range []EncodeOptions{DefaultEncodeOptions(), {ASCIIOnly: true}} {
+		opts.SortMapKeys = true
+
+		// a named type does not take the fast path
+		want, err := MarshalWithOptions(namedMap(m), opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := MarshalWithOptions(m, opts)
+		if err != nil || string(got) != string(want) {
+			t.Errorf("got %#q, %v, want %#q", got, err, want)
+		}
+
+		opts.SortMapKeys = false
+		got, err = MarshalWithOptions(m, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var back interface{}
+		if err := Unmarshal(got, &back); err != nil {
+			t.Fatal(err)
+		}
+		exp, _ := SimpleUnmarshal(want)
+		if !reflect.DeepEqual(back, exp) {
+			t.Errorf("unsorted got %#q, want %#q", got, want)
+		}
+	}
+
+	var buf bytes.Buffer
+	enc := NewEncoder(&buf)
+	enc.SetSortMapKeys(false)
+	if err := enc.Encode(m); err != nil {
+		t.Fatal(err)
+	}
+}
+
+type pointerCycle struct {
+	Ptr *pointerCycle
+}
+
+type mapCycle struct {
+	M map[string]interface{}
+}
+
+func TestMarshalCycles(t *testing.T) {
+	pc := &pointerCycle{}
+	pc.Ptr = pc
+
+	mc := &mapCycle{M: map[string]interface{}{}}
+	mc.M["m"] = mc.M
+
+	sc := []interface{}{nil}
+	sc[0] = sc
+
+	ic := map[int]interface{}{}
+	ic[1] = []interface{}{ic}
+
+	for _, v := range []interface{}{pc, mc, sc, ic} {
+		_, err := Marshal(v)
+		if _, ok := err.(*UnsupportedValueError); !ok {
+			t.Errorf("%T: expected unsupported value error, got %v", v, err)
+		} else if !strings.Contains(err.Error(), "encountered a cycle") {
+			t.Errorf("%T: unexpected error %v", v, err)
+		}
+	}
+
+	// deep, but not cyclic
+	deep := &pointerCycle{}
+	for i := 0; i < 2*startDetectingCyclesAfter; i++ {
+		deep = &pointerCycle{deep}
+	}
+	if _, err := Marshal(deep); err != nil {
+		t.Errorf("deep structure got %v", err)
+	}
+
+	// the same slice twice, at the same depth
+	shared := []int{1}
+	var nested interface{} = []interface{}{shared, shared}
+	for i := 0; i < 2*startDetectingCyclesAfter; i++ {
+		nested = []interface{}{nested}
+	}
+	if _, err := Marshal(nested); err != nil {
+		t.Errorf("shared slice got %v", err)
+	}
+}
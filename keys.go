@@ -35,6 +35,15 @@ type IndexState struct {
 
 // Find a first level field
 func FindKey(data []byte, field string) ([]byte, error) {
+	return findKey(data, field, false)
+}
+
+// as above, but fails on invalid UTF-8 or unpaired UTF-16 surrogates in the part of the document scanned
+func FindKeyStrict(data []byte, field string) ([]byte, error) {
+	return findKey(data, field, true)
+}
+
+func findKey(data []byte, field string, strict bool) ([]byte, error) {
 	var current []byte
 	var sc scanner
 
@@ -44,6 +53,7 @@ func FindKey(data []byte, field string) ([]byte, error) {
 
 	scan := setScanner(&sc, data)
 	scan.reset()
+	scan.strict = strict
 	level := 0
 
 	for scan.offset < len(scan.data) {
@@ -96,6 +106,11 @@ func SetKeyState(state *KeyState, data []byte) {
 	}
 }
 
+// reject invalid UTF-8 and unpaired UTF-16 surrogates from now on
+func (state *KeyState) SetStrict() {
+	state.scan.strict = true
+}
+
 // release state
 func (state *KeyState) Release() {
 	values := state.scan.values[0:0]
@@ -186,6 +201,11 @@ func (state *ScanState) SetImmutable() {
 	state.scan.setImmutable()
 }
 
+// reject invalid UTF-8 and unpaired UTF-16 surrogates from now on
+func (state *ScanState) SetStrict() {
+	state.scan.strict = true
+}
+
 // release state
 func (state *ScanState) Release() {
 	state.scan = scanner{}
@@ -265,6 +285,15 @@ func (state *ScanState) EOS() bool {
 
 // Find an array element
 func FindIndex(data []byte, index int) ([]byte, error) {
+	return findIndex(data, index, false)
+}
+
+// as above, but fails on invalid UTF-8 or unpaired UTF-16 surrogates in the part of the document scanned
+func FindIndexStrict(data []byte, index int) ([]byte, error) {
+	return findIndex(data, index, true)
+}
+
+func findIndex(data []byte, index int, strict bool) ([]byte, error) {
 	var sc scanner
 
 	if index < 0 {
@@ -273,6 +302,7 @@ func FindIndex(data []byte, index int) ([]byte, error) {
 
 	scan := setScanner(&sc, data)
 	scan.reset()
+	scan.strict = strict
 	level := 0
 	position := 0
 
@@ -325,6 +355,11 @@ func SetIndexState(state *IndexState, data []byte) {
 	}
 }
 
+// reject invalid UTF-8 and unpaired UTF-16 surrogates from now on
+func (state *IndexState) SetStrict() {
+	state.scan.strict = true
+}
+
 // release state
 func (state *IndexState) Release() {
 	state.scan = scanner{}
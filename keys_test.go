@@ -89,6 +89,81 @@ func TestFindKey(t *testing.T) {
 	}
 }
 
+func TestFindKeyStrict(t *testing.T) {
+	for _, tt := range strictTests {
+		doc := []byte(`{"a": ` + tt.in + `, "b": 1}`)
+		res, err := FindKeyStrict(doc, "b")
+		if tt.valid {
+			if err != nil || string(res) != "1" {
+				t.Fatalf("%q got %q, %v", doc, res, err)
+			}
+		} else if _, ok := err.(*SyntaxError); !ok {
+			t.Fatalf("%q expected syntax error, got %v", doc, err)
+		}
+
+		var state KeyState
+		SetKeyState(&state, doc)
+		state.SetStrict()
+		_, err = state.FindKey("b")
+		if tt.valid == (err != nil) {
+			t.Fatalf("%q with state got %v", doc, err)
+		}
+		state.Release()
+	}
+
+	// the scan stops once the field is found
+	res, err := FindKeyStrict([]byte("{\"a\": 1, \"b\": \"\xff\"}"), "a")
+	if err != nil || string(res) != "1" {
+		t.Fatalf("expected 1, got %q, %v", res, err)
+	}
+}
+
+// the value looked up is itself checked
+func TestStrictValueFound(t *testing.T) {
+	for _, tt := range strictTests {
+		check := func(what string, res []byte, err error) {
+			if tt.valid {
+				if err != nil || string(res) != tt.in {
+					t.Errorf("%s %q got %q, %v", what, tt.in, res, err)
+				}
+			} else if _, ok := err.(*SyntaxError); !ok {
+				t.Errorf("%s %q expected syntax error, got %q, %v", what, tt.in, res, err)
+			}
+		}
+		obj := []byte(`{"a": ` + tt.in + `, "b": 1}`)
+		arr := []byte(`[` + tt.in + `, 1]`)
+
+		res, err := FindKeyStrict(obj, "a")
+		check("FindKeyStrict", res, err)
+		res, err = FindIndexStrict(arr, 0)
+		check("FindIndexStrict", res, err)
+
+		var keyState KeyState
+		SetKeyState(&keyState, obj)
+		keyState.SetStrict()
+		res, err = keyState.FindKey("a")
+		check("KeyState", res, err)
+		keyState.Release()
+
+		var indexState IndexState
+		SetIndexState(&indexState, arr)
+		indexState.SetStrict()
+		res, err = indexState.FindIndex(0)
+		check("IndexState", res, err)
+		indexState.Release()
+
+		var scanState ScanState
+		SetScanState(&scanState, obj)
+		scanState.SetStrict()
+		if key, err := scanState.ScanKeys(); err != nil || string(key) != "a" {
+			t.Fatalf("%q ScanKeys got %q, %v", obj, key, err)
+		}
+		res, err = scanState.NextValue()
+		check("ScanState", res, err)
+		scanState.Release()
+	}
+}
+
 func TestFindKeyWithState(t *testing.T) {
 	var state KeyState
 
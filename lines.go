@@ -0,0 +1,177 @@
+//  Copyright (c) 2026 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package json
+
+import (
+	"io"
+	"strconv"
+	"strings"
+)
+
+// A LineError describes a line of a JSON Lines stream that could not be read.
+type LineError struct {
+	Line   int   // line number, starting at 1
+	Offset int64 // offset of the start of the line in the stream
+	Err    error // the error, with offsets relative to the start of the line
+}
+
+func (e *LineError) Error() string {
+	return "json: line " + strconv.Itoa(e.Line) + ": " + strings.TrimPrefix(e.Err.Error(), "json: ")
+}
+
+func (e *LineError) Unwrap() error { return e.Err }
+
+// A LinesReader reads JSON Lines (newline delimited JSON) from an input stream.
+// Each line must hold exactly one JSON value; blank lines are skipped.
+type LinesReader struct {
+	dec     *Decoder
+	scan    scanner
+	line    int
+	handler func(*LineError) error
+}
+
+// NewLinesReader returns a new reader of the JSON Lines in r.
+//
+// Like a Decoder, the reader introduces its own buffering and may
+// read data from r beyond the lines requested.
+func NewLinesReader(r io.Reader) *LinesReader {
+	lr := &LinesReader{dec: NewDecoder(r)}
+	setScanner(&lr.scan, nil)
+	return lr
+}
+
+// SetOptions sets the options used to decode each line.
+// SinglePass is ignored, since each line is validated before it is decoded.
+func (lr *LinesReader) SetOptions(opts DecodeOptions) {
+	lr.dec.d.opts = opts
+}
+
+// SetErrorHandler sets a function to be called with the error for each line
+// that is not valid JSON, or cannot be decoded.
+// If fn returns nil, the line is skipped and reading continues with the next one,
+// otherwise the error returned by fn is returned by Read or ReadRaw.
+// Without a handler, the LineError is returned, and the next call continues
+// after the line in error.
+func (lr *LinesReader) SetErrorHandler(fn func(*LineError) error) {
+	lr.handler = fn
+}
+
+// Line returns the number of the last line read.
+func (lr *LinesReader) Line() int {
+	return lr.line
+}
+
+// Read decodes the value on the next non blank line into v.
+// It returns io.EOF at the end of the input.
+//
+// See the documentation for Unmarshal for details about the
+// conversion of JSON into a Go value.
+func (lr *LinesReader) Read(v interface{}) error {
+	for {
+		line, offset, err := lr.next()
+		if err != nil {
+			return err
+		}
+		err = lr.dec.d.init(line).unmarshal(v)
+		if err == nil {
+			return nil
+		}
+		err = lr.lineError(offset, err)
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// ReadRaw returns the value on the next non blank line, without surrounding
+// white space, for use with FindKey and the like.
+// The slice is only valid until the next call to Read or ReadRaw.
+// It returns io.EOF at the end of the input.
+func (lr *LinesReader) ReadRaw() ([]byte, error) {
+	line, _, err := lr.next()
+	return line, err
+}
+
+// next returns the next valid, non blank line and its offset in the stream
+func (lr *LinesReader) next() ([]byte, int64, error) {
+	for {
+		offset := lr.dec.InputOffset()
+		line, err := lr.dec.readUntil('\n')
+		if err != nil {
+			return nil, offset, err
+		}
+		lr.line++
+		if !nonSpace(line) {
+			continue
+		}
+
+		// keep the parse state allocated by previous lines
+		ps := lr.scan.parseState
+		setScanner(&lr.scan, line)
+		lr.scan.parseState = ps
+		err = checkValid(line, &lr.scan)
+		if err == nil {
+			start := 0
+			for isSpace(line[start]) {
+				start++
+			}
+			end := len(line)
+			for isSpace(line[end-1]) {
+				end--
+			}
+			return line[start:end], offset, nil
+		}
+		err = lr.lineError(offset, err)
+		if err != nil {
+			return nil, offset, err
+		}
+	}
+}
+
+// lineError reports err for the current line to the error handler, if any
+func (lr *LinesReader) lineError(offset int64, err error) error {
+	lerr := &LineError{Line: lr.line, Offset: offset, Err: err}
+	if lr.handler == nil {
+		return lerr
+	}
+	return lr.handler(lerr)
+}
+
+// A LinesWriter writes JSON Lines (newline delimited JSON) to an output stream.
+type LinesWriter struct {
+	enc *Encoder
+}
+
+// NewLinesWriter returns a new writer of JSON Lines to w.
+func NewLinesWriter(w io.Writer) *LinesWriter {
+	return &LinesWriter{enc: NewEncoder(w)}
+}
+
+// SetOptions sets the options used to encode each value.
+// Prefix and Indent are ignored, since each value must fit on one line.
+func (lw *LinesWriter) SetOptions(opts EncodeOptions) {
+	opts.Prefix = ""
+	opts.Indent = ""
+	lw.enc.SetOptions(opts)
+}
+
+// Write writes the JSON encoding of v to the stream, as a single line.
+//
+// See the documentation for Marshal for details about the
+// conversion of Go values to JSON.
+func (lw *LinesWriter) Write(v interface{}) error {
+	return lw.enc.Encode(v)
+}
+
+// WriteRaw writes the JSON value in data to the stream, compacted to a single line.
+func (lw *LinesWriter) WriteRaw(data []byte) error {
+	m := RawMessage(data)
+	return lw.enc.Encode(&m)
+}
@@ -0,0 +1,162 @@
+//  Copyright (c) 2026 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package json
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+// tests
+
+const linesDoc = "{\"a\":1}\n\n  [1, 2]  \r\n\"s\"\n{\"a\":\n2}\n3 4\n{\"a\":\"x\"}\n5"
+
+func TestLinesReader(t *testing.T) {
+	var got []interface{}
+	var lines []int
+
+	lr := NewLinesReader(iotest.OneByteReader(strings.NewReader(linesDoc)))
+	lr.SetErrorHandler(func(err *LineError) error {
+		lines = append(lines, err.Line)
+		return nil
+	})
+	for {
+		var v interface{}
+
+		err := lr.Read(&v)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Read got %v", err)
+		}
+		got = append(got, v)
+	}
+	expected := []interface{}{
+		map[string]interface{}{"a": int64(1)},
+		[]interface{}{int64(1), int64(2)},
+		"s",
+		map[string]interface{}{"a": "x"},
+		int64(5),
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Fatalf("expected %v got %v", expected, got)
+	}
+	if !reflect.DeepEqual(lines, []int{5, 6, 7}) {
+		t.Fatalf("expected errors on lines 5, 6, 7, got %v", lines)
+	}
+	if lr.Line() != 9 {
+		t.Fatalf("expected 9 lines, got %v", lr.Line())
+	}
+}
+
+func TestLinesReaderErrors(t *testing.T) {
+	var s struct{ A int }
+
+	lr := NewLinesReader(strings.NewReader(linesDoc))
+	var errs []string
+	for {
+		err := lr.Read(&s)
+		if err == io.EOF {
+			break
+		}
+		if err == nil {
+			continue
+		}
+		lerr, ok := err.(*LineError)
+		if !ok {
+			t.Fatalf("expected LineError, got %v", err)
+		}
+		if lerr.Offset == 0 || linesDoc[lerr.Offset-1] != '\n' {
+			t.Fatalf("line %v: unexpected offset %v", lerr.Line, lerr.Offset)
+		}
+		errs = append(errs, err.Error())
+	}
+	expected := []string{
+		"json: line 3: cannot unmarshal array into Go value of type struct { A int }",
+		"json: line 4: cannot unmarshal string into Go value of type struct { A int }",
+		"json: line 5: unexpected end of JSON input",
+		"json: line 6: invalid character '}' after top-level value",
+		"json: line 7: invalid character '4' after top-level value",
+		"json: line 8: cannot unmarshal string at \"/a\" into Go struct field A of type int",
+		"json: line 9: cannot unmarshal number into Go value of type struct { A int }",
+	}
+	if !reflect.DeepEqual(errs, expected) {
+		t.Fatalf("expected %q\ngot %q", expected, errs)
+	}
+
+	stop := errors.New("stop")
+	lr = NewLinesReader(strings.NewReader(linesDoc))
+	lr.SetErrorHandler(func(err *LineError) error { return stop })
+	for {
+		if err := lr.Read(&s); err != nil {
+			if err != stop || lr.Line() != 3 {
+				t.Fatalf("expected stop at line 3, got %v at %v", err, lr.Line())
+			}
+			break
+		}
+	}
+}
+
+func TestLinesReaderRaw(t *testing.T) {
+	var got []string
+
+	lr := NewLinesReader(strings.NewReader(linesDoc))
+	lr.SetErrorHandler(func(err *LineError) error { return nil })
+	for {
+		raw, err := lr.ReadRaw()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("ReadRaw got %v", err)
+		}
+		got = append(got, string(raw))
+	}
+	expected := []string{"{\"a\":1}", "[1, 2]", "\"s\"", "{\"a\":\"x\"}", "5"}
+	if !reflect.DeepEqual(got, expected) {
+		t.Fatalf("expected %q got %q", expected, got)
+	}
+}
+
+func TestLinesWriter(t *testing.T) {
+	var buf bytes.Buffer
+
+	lw := NewLinesWriter(&buf)
+	lw.SetOptions(EncodeOptions{Indent: "  ", SortMapKeys: true})
+	if err := lw.Write(map[string]interface{}{"b": []int{1, 2}, "a": "<"}); err != nil {
+		t.Fatalf("Write got %v", err)
+	}
+	if err := lw.WriteRaw([]byte("{\n  \"c\": [ 3, 4 ]\n}")); err != nil {
+		t.Fatalf("WriteRaw got %v", err)
+	}
+	if err := lw.WriteRaw([]byte("{")); err == nil {
+		t.Fatal("WriteRaw of invalid JSON expected error")
+	}
+	expected := "{\"a\":\"<\",\"b\":[1,2]}\n{\"c\":[3,4]}\n"
+	if buf.String() != expected {
+		t.Fatalf("expected %q got %q", expected, buf.String())
+	}
+
+	lr := NewLinesReader(&buf)
+	for i := 0; i < 2; i++ {
+		if _, err := lr.ReadRaw(); err != nil {
+			t.Fatalf("ReadRaw got %v", err)
+		}
+	}
+	if _, err := lr.ReadRaw(); err != io.EOF {
+		t.Fatalf("expected EOF, got %v", err)
+	}
+}
@@ -0,0 +1,367 @@
+//  Copyright (c) 2026 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package json
+
+import (
+	"io"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// records are handed to the workers in batches, to amortize the synchronization
+const (
+	maxBatchRecords = 256
+	maxBatchBytes   = 1 << 20
+)
+
+// RecordOptions control the decoding of records by DecodeRecords and DecodeRecordsFrom.
+type RecordOptions struct {
+
+	// number of decoding goroutines, runtime.GOMAXPROCS(0) if not positive
+	Workers int
+
+	// returns a pointer to the value each record is decoded into,
+	// or nil to decode records with SimpleUnmarshal
+	New func() interface{}
+
+	// options used to decode records into the values returned by New
+	DecodeOptions DecodeOptions
+
+	// the input is a sequence of values, even if it starts with an array
+	Sequence bool
+}
+
+// A RecordError describes a record that could not be decoded.
+type RecordError struct {
+	Index int // index of the record, starting at 0
+	Err   error
+}
+
+func (e *RecordError) Error() string {
+	return "json: record " + strconv.Itoa(e.Index) + ": " + strings.TrimPrefix(e.Err.Error(), "json: ")
+}
+
+func (e *RecordError) Unwrap() error { return e.Err }
+
+// SplitRecords validates data and returns the records it holds, as slices of data:
+// the elements of data if it is a single top-level array, or else the top-level
+// values in data, which are separated by white space, as in JSON Lines.
+func SplitRecords(data []byte) ([][]byte, error) {
+	var sc scanner
+
+	scan := setScanner(&sc, data)
+	i := spaceSpan(data, 0)
+	if i < len(data) && data[i] == '[' {
+		records, single, err := splitArray(data, scan)
+		if err != nil || single {
+			return records, err
+		}
+	}
+	return splitSequence(data, scan)
+}
+
+// splitArray returns the elements of the top-level array in data.
+// single is false if other values follow the array
+func splitArray(data []byte, scan *scanner) (records [][]byte, single bool, err error) {
+	scan.reset()
+	scan.offset = 0
+	start := 0
+	literal := false
+	for scan.offset < len(data) {
+		i := scan.offset
+		c := data[i]
+		scan.offset++
+		depth := len(scan.parseState)
+		op := scan.step(scan, c)
+
+		// literals end at the first interesting byte following them
+		if literal && op != scanContinue {
+			records = append(records, data[start:i])
+			literal = false
+		}
+		switch op {
+		case scanBeginLiteral:
+			if depth == 1 {
+				start = i
+				literal = true
+			}
+		case scanBeginObject, scanBeginArray:
+			if depth == 1 {
+				start = i
+			}
+		case scanEndObject, scanEndArray:
+			switch len(scan.parseState) {
+			case 0:
+				if nonSpace(data[scan.offset:]) {
+					return nil, false, nil
+				}
+				return records, true, nil
+			case 1:
+				records = append(records, data[start:scan.offset])
+			}
+		case scanError:
+			return nil, true, scan.err
+		}
+	}
+	scan.eof()
+	return nil, true, scan.err
+}
+
+// splitSequence returns the top-level values in data
+func splitSequence(data []byte, scan *scanner) ([][]byte, error) {
+	var records [][]byte
+
+	scan.offset = spaceSpan(data, 0)
+	for scan.offset < len(data) {
+		value, _, err := nextValue(data, scan)
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, value)
+		scan.offset = spaceSpan(data, scan.offset)
+	}
+	return records, nil
+}
+
+// DecodeRecords decodes the records in data, as returned by SplitRecords,
+// using a pool of goroutines, and calls fn with the index and the value of
+// each record, in order, from the calling goroutine.
+// Decoding stops at the first record that cannot be decoded, which is reported
+// as a RecordError, or at the first error returned by fn, which is returned.
+func DecodeRecords(data []byte, opts RecordOptions, fn func(int, interface{}) error) error {
+	var records [][]byte
+	var err error
+
+	if opts.Sequence {
+		var sc scanner
+
+		records, err = splitSequence(data, setScanner(&sc, data))
+	} else {
+		records, err = SplitRecords(data)
+	}
+	if err != nil {
+		return err
+	}
+	return decodeRecords(func() ([][]byte, error) {
+		if len(records) == 0 {
+			return nil, io.EOF
+		}
+		n := len(records)
+		if n > maxBatchRecords {
+			n = maxBatchRecords
+		}
+		batch := records[:n:n]
+		records = records[n:]
+		return batch, nil
+	}, opts, fn)
+}
+
+// DecodeRecordsFrom is like DecodeRecords, but reads the records from r,
+// so that decoding starts before the whole input has been read.
+// Unless opts.Sequence is set, an input starting with an array is taken to be
+// a single top-level array.
+func DecodeRecordsFrom(r io.Reader, opts RecordOptions, fn func(int, interface{}) error) error {
+	var ends []int
+	var readErr error
+
+	dec := NewDecoder(r)
+	inArray := false
+	if !opts.Sequence {
+		c, err := dec.peek()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if c == '[' {
+			if _, err = dec.Token(); err != nil {
+				return err
+			}
+			inArray = true
+		}
+	}
+	done := false
+	return decodeRecords(func() ([][]byte, error) {
+
+		// records are copied, since the decoder reuses its buffer
+		var buf []byte
+
+		ends = ends[0:0]
+		for !done && readErr == nil && len(ends) < maxBatchRecords && len(buf) < maxBatchBytes {
+			if inArray && !dec.More() {
+				readErr = dec.arrayEnd()
+				done = true
+				break
+			}
+			raw, err := dec.rawValue(false)
+			if err == io.EOF && !inArray {
+				done = true
+				break
+			}
+
+			// records read so far are delivered before the error
+			if err != nil {
+				readErr = err
+				break
+			}
+			buf = append(buf, raw[spaceSpan(raw, 0):]...)
+			ends = append(ends, len(buf))
+		}
+		if len(ends) == 0 {
+			if readErr != nil {
+				return nil, readErr
+			}
+			return nil, io.EOF
+		}
+		records := make([][]byte, len(ends))
+		start := 0
+		for i, end := range ends {
+			records[i] = buf[start:end:end]
+			start = end
+		}
+		return records, nil
+	}, opts, fn)
+}
+
+// arrayEnd consumes the end of a top-level array, which must end the input
+func (dec *Decoder) arrayEnd() error {
+	_, err := dec.Token()
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	if err != nil {
+		return err
+	}
+	c, err := dec.peek()
+	if err == io.EOF {
+		return nil
+	}
+	if err == nil {
+		err = &SyntaxError{msg: "invalid character " + quoteChar(c) + " after top-level value", Offset: dec.InputOffset()}
+	}
+	return err
+}
+
+// a recordBatch is decoded by one worker
+type recordBatch struct {
+	first   int // index of the first record
+	records [][]byte
+	values  []interface{}
+	err     error
+	done    chan struct{}
+}
+
+func (b *recordBatch) decode(d *decodeState, newValue func() interface{}) {
+	var err error
+
+	b.values = make([]interface{}, 0, len(b.records))
+	for i, rec := range b.records {
+		var v interface{}
+
+		// records have already been validated
+		if newValue == nil {
+			v, err = SimpleUnmarshal(rec)
+		} else {
+			v = newValue()
+			err = d.init(rec).unmarshal(v)
+		}
+		if err != nil {
+			b.err = &RecordError{Index: b.first + i, Err: err}
+			return
+		}
+		b.values = append(b.values, v)
+	}
+}
+
+// decodeRecords decodes the batches of records returned by next until it
+// returns io.EOF, and passes the values to fn in order
+func decodeRecords(next func() ([][]byte, error), opts RecordOptions, fn func(int, interface{}) error) error {
+	var wg sync.WaitGroup
+	var nextErr error
+
+	workers := opts.Workers
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	jobs := make(chan *recordBatch)
+	pending := make(chan *recordBatch, 2*workers)
+	stop := make(chan struct{})
+
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			var d decodeState
+
+			defer wg.Done()
+			d.opts = opts.DecodeOptions
+			for b := range jobs {
+				b.decode(&d, opts.New)
+				close(b.done)
+			}
+		}()
+	}
+
+	// batches are queued for delivery in the order they are split
+	go func() {
+		defer close(pending)
+		defer close(jobs)
+		first := 0
+		for {
+			select {
+			case <-stop:
+				return
+			default:
+			}
+			records, err := next()
+			if err != nil {
+				if err != io.EOF {
+					nextErr = err
+				}
+				return
+			}
+			b := &recordBatch{first: first, records: records, done: make(chan struct{})}
+			first += len(records)
+			select {
+			case pending <- b:
+			case <-stop:
+				return
+			}
+			jobs <- b
+		}
+	}()
+
+	var err error
+	for b := range pending {
+		<-b.done
+		if err != nil {
+			continue
+		}
+		for i, v := range b.values {
+			err = fn(b.first+i, v)
+			if err != nil {
+				break
+			}
+		}
+		if err == nil {
+			err = b.err
+		}
+		if err != nil {
+			close(stop)
+		}
+	}
+	wg.Wait()
+	if err == nil {
+		err = nextErr
+	}
+	return err
+}
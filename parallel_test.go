@@ -0,0 +1,207 @@
+//  Copyright (c) 2026 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package json
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+type recordTest struct {
+	N    int
+	Name string
+	Tags []string
+}
+
+// a large enough input to be split in several batches
+func recordsInput(n int, array bool) []byte {
+	var buf bytes.Buffer
+
+	if array {
+		buf.WriteString("[\n")
+	}
+	for i := 0; i < n; i++ {
+		if array && i > 0 {
+			buf.WriteString(",\n")
+		}
+		fmt.Fprintf(&buf, "{\"N\": %d, \"Name\": \"r\\\"%d\", \"Tags\": [\"a\", \"b\"]}", i, i)
+		if !array {
+			buf.WriteByte('\n')
+		}
+	}
+	if array {
+		buf.WriteString("\n]\n")
+	}
+	return buf.Bytes()
+}
+
+// tests
+
+func TestSplitRecords(t *testing.T) {
+	for _, test := range []struct {
+		in       string
+		expected []string
+	}{
+		{"", nil},
+		{" \n ", nil},
+		{"[]", nil},
+		{" [1, \"a,]\" ,{\"b\": [2]}, [[]], true ] ", []string{"1", "\"a,]\"", "{\"b\": [2]}", "[[]]", "true"}},
+		{"{\"a\":1}\n{\"a\":2}\n", []string{"{\"a\":1}", "{\"a\":2}"}},
+		{"1 \"x\"\n[2]\tnull", []string{"1", "\"x\"", "[2]", "null"}},
+
+		// arrays followed by other values are records themselves
+		{"[1, 2]\n[3]\n", []string{"[1, 2]", "[3]"}},
+	} {
+		records, err := SplitRecords([]byte(test.in))
+		if err != nil {
+			t.Fatalf("%q got %v", test.in, err)
+		}
+		var got []string
+		for _, r := range records {
+			got = append(got, string(r))
+		}
+		if !reflect.DeepEqual(got, test.expected) {
+			t.Errorf("%q expected %q got %q", test.in, test.expected, got)
+		}
+	}
+
+	for _, in := range []string{"[1, 2", "[1,]", "{\"a\":1}\n{\"a\"}", "[1] x", "1 2 }"} {
+		if _, err := SplitRecords([]byte(in)); err == nil {
+			t.Errorf("%q expected error", in)
+		}
+	}
+}
+
+func TestDecodeRecords(t *testing.T) {
+	const n = 2000
+
+	for _, array := range []bool{false, true} {
+		data := recordsInput(n, array)
+		for _, workers := range []int{0, 1, 3} {
+			opts := RecordOptions{Workers: workers, New: func() interface{} { return new(recordTest) }}
+			for _, fromReader := range []bool{false, true} {
+				next := 0
+				check := func(i int, v interface{}) error {
+					r := v.(*recordTest)
+					if i != next || r.N != i || r.Name != fmt.Sprintf("r\"%d", i) || len(r.Tags) != 2 {
+						return fmt.Errorf("record %d: unexpected %v", i, r)
+					}
+					next++
+					return nil
+				}
+				var err error
+				if fromReader {
+					err = DecodeRecordsFrom(iotest.HalfReader(bytes.NewReader(data)), opts, check)
+				} else {
+					err = DecodeRecords(data, opts, check)
+				}
+				if err != nil {
+					t.Fatalf("array %v workers %v reader %v: %v", array, workers, fromReader, err)
+				}
+				if next != n {
+					t.Fatalf("array %v workers %v reader %v: expected %v records, got %v", array, workers, fromReader, n, next)
+				}
+			}
+		}
+	}
+
+	// SimpleUnmarshal
+	var got []interface{}
+	err := DecodeRecordsFrom(strings.NewReader("[1, \"a\", {\"b\": null}]"), RecordOptions{}, func(i int, v interface{}) error {
+		got = append(got, v)
+		return nil
+	})
+	expected := []interface{}{int64(1), "a", map[string]interface{}{"b": nil}}
+	if err != nil || !reflect.DeepEqual(got, expected) {
+		t.Fatalf("expected %v got %v %v", expected, got, err)
+	}
+}
+
+func TestDecodeRecordsErrors(t *testing.T) {
+	data := recordsInput(1000, false)
+
+	// a bad record is reported after the ones preceding it
+	bad := bytes.Replace(data, []byte("{\"N\": 700,"), []byte("{\"N\": \"x\","), 1)
+	for _, decode := range []func(func(int, interface{}) error) error{
+		func(fn func(int, interface{}) error) error {
+			return DecodeRecords(bad, RecordOptions{Workers: 4, New: func() interface{} { return new(recordTest) }}, fn)
+		},
+		func(fn func(int, interface{}) error) error {
+			return DecodeRecordsFrom(bytes.NewReader(bad), RecordOptions{Workers: 4, New: func() interface{} { return new(recordTest) }}, fn)
+		},
+	} {
+		count := 0
+		err := decode(func(i int, v interface{}) error {
+			count++
+			return nil
+		})
+		rerr, ok := err.(*RecordError)
+		if !ok || rerr.Index != 700 || count != 700 {
+			t.Fatalf("expected error at record 700, got %v after %v records", err, count)
+		}
+		if _, ok = rerr.Err.(*UnmarshalTypeError); !ok {
+			t.Fatalf("expected UnmarshalTypeError, got %v", rerr.Err)
+		}
+	}
+
+	// errors from fn stop decoding
+	stop := errors.New("stop")
+	count := 0
+	err := DecodeRecords(data, RecordOptions{Workers: 2}, func(i int, v interface{}) error {
+		count++
+		if i == 300 {
+			return stop
+		}
+		return nil
+	})
+	if err != stop || count != 301 {
+		t.Fatalf("expected stop after 301 records, got %v after %v", err, count)
+	}
+
+	// syntax errors are reported after the records preceding them
+	for _, in := range []string{"[1, 2, x]", "[1, 2", "[1, 2] 3", "1 2 ]"} {
+		count = 0
+		err = DecodeRecordsFrom(strings.NewReader(in), RecordOptions{}, func(i int, v interface{}) error {
+			count++
+			return nil
+		})
+		if err == nil || err == io.EOF || count != 2 {
+			t.Errorf("%q expected error after 2 records, got %v after %v", in, err, count)
+		}
+	}
+}
+
+// benchmarks
+
+func benchmarkDecodeRecords(b *testing.B, workers int) {
+	data := recordsInput(10000, false)
+	b.SetBytes(int64(len(data)))
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		err := DecodeRecords(data, RecordOptions{Workers: workers}, func(int, interface{}) error { return nil })
+		if err != nil {
+			b.Fatal("DecodeRecords:", err)
+		}
+	}
+}
+
+func BenchmarkDecodeRecords1(b *testing.B) {
+	benchmarkDecodeRecords(b, 1)
+}
+
+func BenchmarkDecodeRecords(b *testing.B) {
+	benchmarkDecodeRecords(b, 0)
+}
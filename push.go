@@ -0,0 +1,250 @@
+//  Copyright (c) 2026 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package json
+
+// An EventKind identifies a significant point in a JSON document
+type EventKind int
+
+const (
+	EventBeginObject EventKind = iota + 1
+	EventEndObject
+	EventBeginArray
+	EventEndArray
+	EventKey
+	EventString
+	EventNumber
+	EventTrue
+	EventFalse
+	EventNull
+)
+
+var eventNames = []string{"", "BeginObject", "EndObject", "BeginArray", "EndArray",
+	"Key", "String", "Number", "True", "False", "Null"}
+
+func (k EventKind) String() string {
+	if k > 0 && int(k) < len(eventNames) {
+		return eventNames[k]
+	}
+	return "Unknown"
+}
+
+// An Event is reported for each key, literal and delimiter in a document.
+// Raw holds the bytes of keys and literals as found in the input, quotes and
+// escapes included, and is only valid until the next event is produced.
+// Offset is the position of the first byte of the token in the input.
+type Event struct {
+	Kind   EventKind
+	Raw    []byte
+	Offset int64
+}
+
+// literalKind determines the kind of a literal from its first byte
+func literalKind(c byte, scan *scanner) EventKind {
+	switch c {
+	case '"':
+		n := len(scan.parseState)
+		if n > 0 && scan.parseState[n-1] == parseObjectKey {
+			return EventKey
+		}
+		return EventString
+	case 't':
+		return EventTrue
+	case 'f':
+		return EventFalse
+	case 'n':
+		return EventNull
+	}
+	return EventNumber
+}
+
+// A PushParser parses a stream of JSON values fed to it in arbitrary chunks,
+// and reports events to a handler as soon as they are complete.
+// The parse state, including partial strings and numbers, is kept across
+// chunk boundaries, so that documents can be processed without reassembly.
+// Consecutive top level values are allowed, as they are for a Decoder.
+type PushParser struct {
+	scan    scanner
+	handler func(Event) error
+	err     error
+
+	// offset in the stream of the current chunk
+	base int64
+
+	// a top level value is being parsed
+	inValue bool
+
+	// the literal being parsed, bytes from previous chunks are kept in literal
+	inLiteral    bool
+	literal      []byte
+	literalKind  EventKind
+	literalStart int64
+}
+
+// NewPushParser returns a new push parser reporting events to handler.
+// If handler returns an error, parsing stops and the error is returned
+// by Write and Close.
+func NewPushParser(handler func(Event) error) *PushParser {
+	p := &PushParser{handler: handler}
+	setScanner(&p.scan, nil)
+
+	// we expect other data after each value
+	p.scan.checkTop = false
+	p.scan.reset()
+	return p
+}
+
+// Reset prepares the parser for a new stream
+func (p *PushParser) Reset() {
+	p.scan.reset()
+	p.scan.data = nil
+	p.scan.offset = 0
+	p.err = nil
+	p.base = 0
+	p.inValue = false
+	p.inLiteral = false
+	p.literal = p.literal[0:0]
+}
+
+// Write parses the next chunk of the stream, reporting all the events it completes.
+// It implements io.Writer. The parser does not retain chunk.
+func (p *PushParser) Write(chunk []byte) (int, error) {
+	if p.err != nil {
+		return 0, p.err
+	}
+
+	scan := &p.scan
+	scan.data = chunk
+	scan.offset = 0
+	literalStart := 0
+	for scan.offset < len(chunk) {
+		i := scan.offset
+		c := chunk[i]
+		scan.offset++
+		newOp := scan.step(scan, c)
+
+		// literals end at the first interesting byte following them
+		if p.inLiteral && newOp != scanContinue {
+			if err := p.endLiteral(chunk[literalStart:i]); err != nil {
+				return i, err
+			}
+		}
+
+		var err error
+		switch newOp {
+		case scanBeginLiteral:
+			p.inValue = true
+			p.inLiteral = true
+			p.literalKind = literalKind(c, scan)
+			p.literalStart = p.base + int64(i)
+			literalStart = i
+		case scanBeginObject:
+			p.inValue = true
+			err = p.emit(EventBeginObject, nil, i)
+		case scanBeginArray:
+			p.inValue = true
+			err = p.emit(EventBeginArray, nil, i)
+		case scanEndObject, scanEndArray:
+			kind := EventEndObject
+			if newOp == scanEndArray {
+				kind = EventEndArray
+			}
+			err = p.emit(kind, nil, i)
+
+			// top level value done, get ready for the next
+			if len(scan.parseState) == 0 {
+				p.inValue = false
+				scan.reset()
+			}
+		case scanEnd:
+
+			// a top level literal ended before this byte, which starts the next value
+			p.inValue = false
+			scan.reset()
+			scan.offset = i
+		case scanError:
+			err = p.syntaxError()
+		}
+		if err != nil {
+			return i, err
+		}
+	}
+
+	// save what we have of the current literal for the next chunk
+	if p.inLiteral {
+		p.literal = append(p.literal, chunk[literalStart:]...)
+	}
+	p.base += int64(len(chunk))
+	scan.data = nil
+	scan.offset = 0
+	return len(chunk), nil
+}
+
+// Close tells the parser that the stream has ended.
+// It returns an error if the stream ended in the middle of a value.
+func (p *PushParser) Close() error {
+	if p.err != nil {
+		return p.err
+	}
+
+	// a space terminates any pending number
+	scan := &p.scan
+	newOp := scan.step(scan, ' ')
+	if p.inLiteral && newOp != scanContinue {
+		if err := p.endLiteral(nil); err != nil {
+			return err
+		}
+	}
+	switch newOp {
+	case scanError:
+		return p.syntaxError()
+	case scanEnd:
+		p.inValue = false
+		scan.reset()
+	}
+	if p.inValue {
+		p.err = &SyntaxError{"unexpected end of JSON input", p.base}
+		return p.err
+	}
+	return nil
+}
+
+func (p *PushParser) emit(kind EventKind, raw []byte, offset int) error {
+	err := p.handler(Event{Kind: kind, Raw: raw, Offset: p.base + int64(offset)})
+	if err != nil {
+		p.err = err
+	}
+	return err
+}
+
+// endLiteral reports the current literal, tail being what is in the current chunk
+func (p *PushParser) endLiteral(tail []byte) error {
+	raw := tail
+	if len(p.literal) > 0 {
+		p.literal = append(p.literal, tail...)
+		raw = p.literal
+	}
+	p.inLiteral = false
+	err := p.handler(Event{Kind: p.literalKind, Raw: raw, Offset: p.literalStart})
+	p.literal = p.literal[0:0]
+	if err != nil {
+		p.err = err
+	}
+	return err
+}
+
+// syntaxError records the scanner error, with an offset relative to the stream
+func (p *PushParser) syntaxError() error {
+	err := p.scan.err
+	if se, ok := err.(*SyntaxError); ok {
+		err = &SyntaxError{se.msg, p.base + se.Offset}
+	}
+	p.err = err
+	return err
+}
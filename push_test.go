@@ -0,0 +1,160 @@
+//  Copyright (c) 2026 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package json
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+var pushDoc = []byte(` {"a": [1, -2.5e+3, true, false, null], "b\"c": {"d": "xèy"}, "e": []} 12345 "top" [{}]`)
+
+var pushEvents = []string{
+	"BeginObject@1",
+	"Key@2 \"a\"",
+	"BeginArray@7",
+	"Number@8 1",
+	"Number@11 -2.5e+3",
+	"True@20 true",
+	"False@26 false",
+	"Null@33 null",
+	"EndArray@37",
+	"Key@40 \"b\\\"c\"",
+	"BeginObject@48",
+	"Key@49 \"d\"",
+	"String@54 \"xèy\"",
+	"EndObject@60",
+	"Key@63 \"e\"",
+	"BeginArray@68",
+	"EndArray@69",
+	"EndObject@70",
+	"Number@72 12345",
+	"String@78 \"top\"",
+	"BeginArray@84",
+	"BeginObject@85",
+	"EndObject@86",
+	"EndArray@87",
+}
+
+func pushEventString(ev Event) string {
+	if ev.Raw == nil {
+		return fmt.Sprintf("%v@%v", ev.Kind, ev.Offset)
+	}
+	return fmt.Sprintf("%v@%v %s", ev.Kind, ev.Offset, ev.Raw)
+}
+
+// tests
+
+func TestPushParser(t *testing.T) {
+	for size := 1; size <= len(pushDoc); size++ {
+		var events []string
+
+		p := NewPushParser(func(ev Event) error {
+			events = append(events, pushEventString(ev))
+			return nil
+		})
+		for i := 0; i < len(pushDoc); i += size {
+			end := i + size
+			if end > len(pushDoc) {
+				end = len(pushDoc)
+			}
+			n, err := p.Write(pushDoc[i:end])
+			if err != nil || n != end-i {
+				t.Fatalf("chunk size %v: Write returned %v, %v", size, n, err)
+			}
+		}
+		if err := p.Close(); err != nil {
+			t.Fatalf("chunk size %v: Close returned %v", size, err)
+		}
+		if !reflect.DeepEqual(events, pushEvents) {
+			t.Fatalf("chunk size %v: expected %q got %q", size, pushEvents, events)
+		}
+	}
+}
+
+func TestPushParserErrors(t *testing.T) {
+	for _, tt := range []struct {
+		in     string
+		offset int64
+	}{
+		{`{"a" 1}`, 6},
+		{`[1, 2`, 5},
+		{`"abc`, 4},
+		{`[1,]`, 4},
+		{`tru`, 3},
+		{`{"a": 1}}`, 9},
+	} {
+		p := NewPushParser(func(ev Event) error {
+			return nil
+		})
+		var err error
+		for i := 0; i < len(tt.in) && err == nil; i++ {
+			_, err = p.Write([]byte(tt.in[i : i+1]))
+		}
+		if err == nil {
+			err = p.Close()
+		}
+		se, ok := err.(*SyntaxError)
+		if !ok {
+			t.Fatalf("%q expected syntax error, got %v", tt.in, err)
+		}
+		if se.Offset != tt.offset {
+			t.Errorf("%q expected error at offset %v, got %v (%v)", tt.in, tt.offset, se.Offset, se)
+		}
+	}
+
+	stop := fmt.Errorf("stop")
+	p := NewPushParser(func(ev Event) error {
+		if ev.Kind == EventNumber {
+			return stop
+		}
+		return nil
+	})
+	if n, err := p.Write([]byte(`["a", 2, 3]`)); err != stop || n != 7 {
+		t.Fatalf("expected handler error at 7, got %v, %v", n, err)
+	}
+	if err := p.Close(); err != stop {
+		t.Fatalf("expected handler error on Close, got %v", err)
+	}
+	p.Reset()
+	if _, err := p.Write([]byte(`["a"]`)); err != nil {
+		t.Fatalf("Write after Reset got %v", err)
+	}
+}
+
+// benchmarks
+
+func BenchmarkPushParserCode(b *testing.B) {
+	if codeJSON == nil {
+		b.StopTimer()
+		codeInit()
+		b.StartTimer()
+	}
+	p := NewPushParser(func(ev Event) error {
+		return nil
+	})
+	b.SetBytes(int64(len(codeJSON)))
+	for i := 0; i < b.N; i++ {
+		p.Reset()
+		for j := 0; j < len(codeJSON); j += 1500 {
+			end := j + 1500
+			if end > len(codeJSON) {
+				end = len(codeJSON)
+			}
+			if _, err := p.Write(codeJSON[j:end]); err != nil {
+				b.Fatal("Write:", err)
+			}
+		}
+		if err := p.Close(); err != nil {
+			b.Fatal("Close:", err)
+		}
+	}
+}
@@ -0,0 +1,130 @@
+//  Copyright (c) 2026 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package json
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+// A Registry holds custom encoders and decoders for types, which take
+// precedence over the types' own MarshalJSON and UnmarshalJSON methods, if any.
+// It is used by setting the Registry field of EncodeOptions or DecodeOptions,
+// so that different registries can encode the same type differently.
+// A Registry is safe for concurrent use, but types should be registered before
+// it is first used, as registering resets its cache of encoders.
+type Registry struct {
+	mu       sync.RWMutex
+	encoders map[reflect.Type]reflect.Value
+	decoders map[reflect.Type]reflect.Value
+
+	// encoders built for types using this registry
+	encoderCache encoderMap
+}
+
+var (
+	bytesType = reflect.TypeOf([]byte(nil))
+	errorType = reflect.TypeOf((*error)(nil)).Elem()
+)
+
+// NewRegistry returns an empty registry
+func NewRegistry() *Registry {
+	return &Registry{
+		encoders: make(map[reflect.Type]reflect.Value),
+		decoders: make(map[reflect.Type]reflect.Value),
+	}
+}
+
+// RegisterEncoder registers fn, which must be a func(T) ([]byte, error),
+// as the encoder for values of type T.
+// The bytes it returns must be valid JSON.
+func (r *Registry) RegisterEncoder(fn interface{}) error {
+	f := reflect.ValueOf(fn)
+	t := f.Type()
+	if t.Kind() != reflect.Func || t.NumIn() != 1 || t.NumOut() != 2 ||
+		t.Out(0) != bytesType || t.Out(1) != errorType {
+		return fmt.Errorf("json: encoder must be a func(T) ([]byte, error), got %v", t)
+	}
+	r.mu.Lock()
+	r.encoders[t.In(0)] = f
+	r.mu.Unlock()
+
+	r.encoderCache.Lock()
+	r.encoderCache.m = nil
+	r.encoderCache.Unlock()
+	return nil
+}
+
+// RegisterDecoder registers fn, which must be a func([]byte, *T) error,
+// as the decoder for values of type T.
+// Like UnmarshalJSON, fn must copy the JSON data if it wishes to retain it.
+func (r *Registry) RegisterDecoder(fn interface{}) error {
+	f := reflect.ValueOf(fn)
+	t := f.Type()
+	if t.Kind() != reflect.Func || t.NumIn() != 2 || t.NumOut() != 1 ||
+		t.In(0) != bytesType || t.In(1).Kind() != reflect.Ptr || t.Out(0) != errorType {
+		return fmt.Errorf("json: decoder must be a func([]byte, *T) error, got %v", t)
+	}
+	r.mu.Lock()
+	r.decoders[t.In(1).Elem()] = f
+	r.mu.Unlock()
+	return nil
+}
+
+// encoder returns the encoderFunc for the encoder registered for t, if any
+func (r *Registry) encoder(t reflect.Type) encoderFunc {
+	if r == nil {
+		return nil
+	}
+	r.mu.RLock()
+	f, ok := r.encoders[t]
+	r.mu.RUnlock()
+	if !ok {
+		return nil
+	}
+	return func(e *encodeState, v reflect.Value, opts encOpts) {
+		out := f.Call([]reflect.Value{v})
+		err, _ := out[1].Interface().(error)
+		if err == nil {
+			// copy JSON into buffer, checking validity.
+			err = compact(&e.Buffer, out[0].Bytes(), opts.escapeHTML)
+		}
+		if err != nil {
+			e.error(&MarshalerError{Type: v.Type(), Err: err})
+		}
+	}
+}
+
+// decoder returns an Unmarshaler that decodes into the value v points to,
+// using the decoder registered for its type, if any
+func (r *Registry) decoder(v reflect.Value) Unmarshaler {
+	if r == nil {
+		return nil
+	}
+	r.mu.RLock()
+	f, ok := r.decoders[v.Type().Elem()]
+	r.mu.RUnlock()
+	if !ok {
+		return nil
+	}
+	return &registeredUnmarshaler{f, v}
+}
+
+type registeredUnmarshaler struct {
+	fn reflect.Value
+	v  reflect.Value
+}
+
+func (u *registeredUnmarshaler) UnmarshalJSON(data []byte) error {
+	out := u.fn.Call([]reflect.Value{reflect.ValueOf(data), u.v})
+	err, _ := out[0].Interface().(error)
+	return err
+}
@@ -0,0 +1,154 @@
+//  Copyright (c) 2026 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package json
+
+import (
+	"fmt"
+	"net"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+type registryDoc struct {
+	Addr  net.IP                 `json:"addr"`
+	Hosts []net.IP               `json:"hosts"`
+	When  *time.Time             `json:"when"`
+	Any   interface{}            `json:"any"`
+	Extra map[string]interface{} `json:"extra"`
+}
+
+// tests
+
+func TestRegistry(t *testing.T) {
+	hex := NewRegistry()
+	err := hex.RegisterEncoder(func(ip net.IP) ([]byte, error) {
+		return []byte(fmt.Sprintf(`"%x"`, []byte(ip.To4()))), nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = hex.RegisterEncoder(func(tm time.Time) ([]byte, error) {
+		return []byte(fmt.Sprint(tm.Unix())), nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = hex.RegisterDecoder(func(data []byte, ip *net.IP) error {
+		var s string
+		if err := Unmarshal(data, &s); err != nil {
+			return err
+		}
+		_, err := fmt.Sscanf(s, "%x", ip)
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = hex.RegisterDecoder(func(data []byte, tm *time.Time) error {
+		var secs int64
+		if err := Unmarshal(data, &secs); err != nil {
+			return err
+		}
+		*tm = time.Unix(secs, 0).UTC()
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tm := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	doc := registryDoc{
+		Addr:  net.IPv4(10, 0, 0, 1),
+		Hosts: []net.IP{net.IPv4(127, 0, 0, 1)},
+		When:  &tm,
+		Any:   net.IPv4(1, 2, 3, 4),
+		Extra: map[string]interface{}{"t": tm},
+	}
+
+	// the default encoding is unchanged
+	got, err := Marshal(doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"addr":"10.0.0.1","hosts":["127.0.0.1"],"when":"2026-01-02T03:04:05Z","any":"1.2.3.4","extra":{"t":"2026-01-02T03:04:05Z"}}`
+	if string(got) != want {
+		t.Errorf("expected %s got %s", want, got)
+	}
+
+	opts := DefaultEncodeOptions()
+	opts.Registry = hex
+	got, err = MarshalWithOptions(doc, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want = `{"addr":"0a000001","hosts":["7f000001"],"when":1767323045,"any":"01020304","extra":{"t":1767323045}}`
+	if string(got) != want {
+		t.Errorf("expected %s got %s", want, got)
+	}
+
+	var back registryDoc
+	err = UnmarshalWithOptions(got, &back, DecodeOptions{Registry: hex})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if back.When == nil || !back.When.Equal(tm) || !back.Addr.Equal(doc.Addr) || !back.Hosts[0].Equal(doc.Hosts[0]) {
+		t.Errorf("expected %+v got %+v", doc, back)
+	}
+
+	var times []time.Time
+	dec := NewDecoder(strings.NewReader(`[0, 60]`))
+	dec.SetRegistry(hex)
+	if err := dec.Decode(&times); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(times, []time.Time{time.Unix(0, 0).UTC(), time.Unix(60, 0).UTC()}) {
+		t.Errorf("Decoder got %v", times)
+	}
+}
+
+func TestRegistryErrors(t *testing.T) {
+	r := NewRegistry()
+	for _, fn := range []interface{}{
+		func(int) []byte { return nil },
+		func(int) (string, error) { return "", nil },
+		func(int, int) ([]byte, error) { return nil, nil },
+	} {
+		if err := r.RegisterEncoder(fn); err == nil {
+			t.Errorf("expected error registering encoder %T", fn)
+		}
+	}
+	for _, fn := range []interface{}{
+		func([]byte, int) error { return nil },
+		func(string, *int) error { return nil },
+		func([]byte, *int) {},
+	} {
+		if err := r.RegisterDecoder(fn); err == nil {
+			t.Errorf("expected error registering decoder %T", fn)
+		}
+	}
+
+	fail := fmt.Errorf("fail")
+	r.RegisterEncoder(func(int) ([]byte, error) { return nil, fail })
+	r.RegisterDecoder(func([]byte, *int) error { return fail })
+	if _, err := MarshalWithOptions([]int{1}, EncodeOptions{Registry: r}); err == nil || !strings.Contains(err.Error(), "fail") {
+		t.Errorf("expected encoder error, got %v", err)
+	}
+	var n []int
+	if err := UnmarshalWithOptions([]byte(`[1]`), &n, DecodeOptions{Registry: r}); err != fail {
+		t.Errorf("expected decoder error, got %v", err)
+	}
+
+	r.RegisterEncoder(func(int) ([]byte, error) { return []byte("{"), nil })
+	if _, err := MarshalWithOptions(1, EncodeOptions{Registry: r}); err == nil {
+		t.Errorf("expected invalid JSON error")
+	}
+}
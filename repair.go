@@ -0,0 +1,69 @@
+//  Copyright (c) 2026 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package json
+
+import (
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// RepairUTF8 rewrites invalid UTF-8 sequences in data as U+FFFD, and unpaired
+// UTF-16 surrogates in string \u escapes as \ufffd, so that a document that
+// is otherwise valid passes ValidateStrict.
+// data is returned unchanged if there is nothing to repair, otherwise
+// the repaired document is returned in a new slice.
+func RepairUTF8(data []byte) []byte {
+	var out []byte
+
+	inString := false
+	copied := 0
+	i := 0
+	for i < len(data) {
+		c := data[i]
+		switch {
+		case c >= utf8.RuneSelf:
+			r, size := utf8.DecodeRune(data[i:])
+			if r == utf8.RuneError && size == 1 {
+				out = append(out, data[copied:i]...)
+				out = append(out, "\uFFFD"...)
+				copied = i + 1
+			}
+			i += size
+		case c == '"':
+			inString = !inString
+			i++
+		case c == '\\' && inString:
+			if i+1 < len(data) && data[i+1] == 'u' {
+				r := getu4(data[i:])
+				if utf16.IsSurrogate(r) {
+					if utf16.DecodeRune(r, getu4(data[i+6:])) != utf8.RuneError {
+						i += 12
+						continue
+					}
+					out = append(out, data[copied:i]...)
+					out = append(out, `\ufffd`...)
+					copied = i + 6
+				}
+			}
+
+			// skip the escaped character, so that \" does not end the string
+			i += 2
+		default:
+			i++
+		}
+	}
+	if out == nil {
+		return data
+	}
+	if copied < len(data) {
+		out = append(out, data[copied:]...)
+	}
+	return out
+}
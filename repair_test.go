@@ -0,0 +1,44 @@
+//  Copyright (c) 2026 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package json
+
+import (
+	"testing"
+)
+
+// tests
+
+func TestRepairUTF8(t *testing.T) {
+	for _, tt := range strictTests {
+		in := []byte(tt.in)
+		out := RepairUTF8(in)
+		if tt.valid {
+			if &out[0] != &in[0] || len(out) != len(in) {
+				t.Errorf("%q was modified to %q", in, out)
+			}
+			continue
+		}
+		if err := ValidateStrict(out); err != nil {
+			t.Errorf("%q repaired to %q got %v", in, out, err)
+		}
+	}
+
+	for _, tt := range []struct{ in, out string }{
+		{"\"a\xffb\"", "\"a\xef\xbf\xbdb\""},
+		{"[\"\xc3\", 1]", "[\"\xef\xbf\xbd\", 1]"},
+		{`"\ud800\\ud800"`, `"\ufffd\\ud800"`},
+		{`"\"\udc00"`, `"\"\ufffd"`},
+		{`"\ud800\ud800"`, `"\ufffd\ufffd"`},
+	} {
+		if out := string(RepairUTF8([]byte(tt.in))); out != tt.out {
+			t.Errorf("%q expected %q, got %q", tt.in, tt.out, out)
+		}
+	}
+}
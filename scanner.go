@@ -14,7 +14,10 @@ package json
 // before diving into the scanner itself.
 
 import (
+	"encoding/binary"
+	"math/bits"
 	"strconv"
+	"unicode/utf8"
 	"unsafe"
 )
 
@@ -43,6 +46,15 @@ func Validate(data []byte) error {
 	return checkValid(data, scan)
 }
 
+// As above, but also rejects invalid UTF-8 and unpaired UTF-16 surrogates in \u escapes.
+func ValidateStrict(data []byte) error {
+	var sc scanner
+
+	scan := setScanner(&sc, data)
+	scan.strict = true
+	return checkValid(data, scan)
+}
+
 // nextValue splits data after the next whole JSON value,
 // returning that value and the bytes that follow it as separate slices.
 // scan is passed in for use by nextValue to avoid an allocation.
@@ -125,6 +137,13 @@ type scanner struct {
 	// NumberParsing
 	useInts bool
 
+	// strict UTF-8 and UTF-16 surrogate checking
+	strict bool
+
+	// the \u escape being scanned, and whether it must be followed by a low surrogate
+	escape        rune
+	highSurrogate bool
+
 	// 1-byte redo (see undo method)
 	redo      bool
 	redoCode  int
@@ -174,6 +193,7 @@ func (s *scanner) reset() {
 	s.err = nil
 	s.redo = false
 	s.endTop = false
+	s.highSurrogate = false
 }
 
 // string conversion
@@ -247,21 +267,93 @@ func isSpace(c byte) bool {
 // they will be skipped in one go before the next token
 func (s *scanner) skipSpaces(c byte) bool {
 	if isSpace(c) {
-		l := len(s.data)
+		s.offset = spaceSpan(s.data, s.offset)
+		return true
+	}
+	return false
+}
 
-		for {
-			if s.offset >= l {
-				return true
-			}
-			c = s.data[s.offset]
-			if isSpace(c) {
-				s.offset++
-			} else {
-				return true
-			}
+// word at a time scanning: bytes are examined 8 at a time, loaded in a uint64
+// the masks below have the high bit set for each byte that satisfies the test
+const (
+	swarOnes  = 0x0101010101010101
+	swarHighs = 0x8080808080808080
+	swarLows  = 0x7f7f7f7f7f7f7f7f
+)
+
+// swarStringStop flags quotes, backslashes and control characters
+// borrows can produce false positives, but only in the bytes following a
+// true positive, so only the lowest flagged byte can be relied upon
+func swarStringStop(x uint64) uint64 {
+	q := x ^ (swarOnes * '"')
+	b := x ^ (swarOnes * '\\')
+	return ((q-swarOnes)&^q | (b-swarOnes)&^b | (x-swarOnes*0x20)&^x) & swarHighs
+}
+
+// swarZero flags the bytes that are zero, exactly
+func swarZero(x uint64) uint64 {
+	return ^((x&swarLows + swarLows) | x | swarLows)
+}
+
+// swarNonSpace flags all the bytes that are not blanks
+func swarNonSpace(x uint64) uint64 {
+	return ^(swarZero(x^(swarOnes*' ')) | swarZero(x^(swarOnes*'\t')) |
+		swarZero(x^(swarOnes*'\n')) | swarZero(x^(swarOnes*'\r'))) & swarHighs
+}
+
+// stringSpan returns the offset of the first quote, backslash or control
+// character in data at or after i, or len(data) if there is none
+func stringSpan(data []byte, i int) int {
+	for ; i+8 <= len(data); i += 8 {
+		m := swarStringStop(binary.LittleEndian.Uint64(data[i:]))
+		if m != 0 {
+			return i + bits.TrailingZeros64(m)>>3
 		}
 	}
-	return false
+	for ; i < len(data); i++ {
+		c := data[i]
+		if c == '"' || c == '\\' || c < 0x20 {
+			break
+		}
+	}
+	return i
+}
+
+// asciiStringSpan is like stringSpan, but also stops at non ASCII bytes
+func asciiStringSpan(data []byte, i int) int {
+	for ; i+8 <= len(data); i += 8 {
+		x := binary.LittleEndian.Uint64(data[i:])
+		m := swarStringStop(x) | x&swarHighs
+		if m != 0 {
+			return i + bits.TrailingZeros64(m)>>3
+		}
+	}
+	for ; i < len(data); i++ {
+		c := data[i]
+		if c == '"' || c == '\\' || c < 0x20 || c >= 0x80 {
+			break
+		}
+	}
+	return i
+}
+
+// spaceSpan returns the offset of the first non blank in data at or after i,
+// or len(data) if there is none
+func spaceSpan(data []byte, i int) int {
+
+	// most runs are short: avoid the word load for single separators
+	if i < len(data) && !isSpace(data[i]) {
+		return i
+	}
+	for ; i+8 <= len(data); i += 8 {
+		m := swarNonSpace(binary.LittleEndian.Uint64(data[i:]))
+		if m != 0 {
+			return i + bits.TrailingZeros64(m)>>3
+		}
+	}
+	for ; i < len(data) && isSpace(data[i]); i++ {
+	}
+	return i
 }
 
 // stateBeginValueOrEmpty is the state after reading `[`.
@@ -404,6 +496,9 @@ func stateEndTop(s *scanner, c byte) int {
 
 // stateInString is the state after reading `"`.
 func stateInString(s *scanner, c byte) int {
+	if s.strict {
+		return stateInStringStrict(s, c)
+	}
 	l := len(s.data)
 	for {
 		if c == '"' {
@@ -417,6 +512,47 @@ func stateInString(s *scanner, c byte) int {
 		if c < 0x20 {
 			return s.error(c, "in string literal")
 		}
+
+		// skip to the next interesting byte
+		s.offset = stringSpan(s.data, s.offset)
+		if s.offset >= l {
+			break
+		}
+		c = s.data[s.offset]
+		s.offset++
+	}
+	return scanContinue
+}
+
+// stateInStringStrict is stateInString, validating UTF-8 sequences on the way.
+// It expects c to be the byte preceding s.offset.
+func stateInStringStrict(s *scanner, c byte) int {
+	if s.highSurrogate && c != '\\' {
+		return s.encodingError("unpaired surrogate in \\u escape")
+	}
+	l := len(s.data)
+	for {
+		if c == '"' {
+			s.step = stateEndValue
+			return scanContinue
+		}
+		if c == '\\' {
+			s.step = stateInStringEsc
+			return scanContinue
+		}
+		if c < 0x20 {
+			return s.error(c, "in string literal")
+		}
+		if c >= utf8.RuneSelf {
+			r, size := utf8.DecodeRune(s.data[s.offset-1:])
+			if r == utf8.RuneError && size == 1 {
+				return s.encodingError("invalid UTF-8 in string literal")
+			}
+			s.offset += size - 1
+		}
+
+		// skip to the next interesting byte
+		s.offset = asciiStringSpan(s.data, s.offset)
 		if s.offset >= l {
 			break
 		}
@@ -428,6 +564,9 @@ func stateInString(s *scanner, c byte) int {
 
 // stateInStringEsc is the state after reading `"\` during a quoted string.
 func stateInStringEsc(s *scanner, c byte) int {
+	if s.highSurrogate && c != 'u' {
+		return s.encodingError("unpaired surrogate in \\u escape")
+	}
 	switch c {
 	case 'b', 'f', 'n', 'r', 't', '\\', '/', '"':
 		s.step = stateInString
@@ -442,6 +581,7 @@ func stateInStringEsc(s *scanner, c byte) int {
 // stateInStringEscU is the state after reading `"\u` during a quoted string.
 func stateInStringEscU(s *scanner, c byte) int {
 	if '0' <= c && c <= '9' || 'a' <= c && c <= 'f' || 'A' <= c && c <= 'F' {
+		s.escape = unhex(c)
 		s.step = stateInStringEscU1
 		return scanContinue
 	}
@@ -452,6 +592,7 @@ func stateInStringEscU(s *scanner, c byte) int {
 // stateInStringEscU1 is the state after reading `"\u1` during a quoted string.
 func stateInStringEscU1(s *scanner, c byte) int {
 	if '0' <= c && c <= '9' || 'a' <= c && c <= 'f' || 'A' <= c && c <= 'F' {
+		s.escape = s.escape<<4 | unhex(c)
 		s.step = stateInStringEscU12
 		return scanContinue
 	}
@@ -462,6 +603,7 @@ func stateInStringEscU1(s *scanner, c byte) int {
 // stateInStringEscU12 is the state after reading `"\u12` during a quoted string.
 func stateInStringEscU12(s *scanner, c byte) int {
 	if '0' <= c && c <= '9' || 'a' <= c && c <= 'f' || 'A' <= c && c <= 'F' {
+		s.escape = s.escape<<4 | unhex(c)
 		s.step = stateInStringEscU123
 		return scanContinue
 	}
@@ -472,13 +614,47 @@ func stateInStringEscU12(s *scanner, c byte) int {
 // stateInStringEscU123 is the state after reading `"\u123` during a quoted string.
 func stateInStringEscU123(s *scanner, c byte) int {
 	if '0' <= c && c <= '9' || 'a' <= c && c <= 'f' || 'A' <= c && c <= 'F' {
+		s.escape = s.escape<<4 | unhex(c)
 		s.step = stateInString
+		if s.strict {
+			return s.checkSurrogate()
+		}
 		return scanContinue
 	}
 	// numbers
 	return s.error(c, "in \\u hexadecimal character escape")
 }
 
+// unhex returns the value of hexadecimal digit c, which is known to be valid
+func unhex(c byte) rune {
+	switch {
+	case c <= '9':
+		return rune(c - '0')
+	case c >= 'a':
+		return rune(c - 'a' + 10)
+	}
+	return rune(c - 'A' + 10)
+}
+
+// checkSurrogate verifies that the \u escape just scanned is not part of an unpaired surrogate
+func (s *scanner) checkSurrogate() int {
+	switch {
+	case s.escape >= 0xd800 && s.escape < 0xdc00:
+		if s.highSurrogate {
+			return s.encodingError("unpaired surrogate in \\u escape")
+		}
+		s.highSurrogate = true
+	case s.escape >= 0xdc00 && s.escape < 0xe000:
+		if !s.highSurrogate {
+			return s.encodingError("unpaired surrogate in \\u escape")
+		}
+		s.highSurrogate = false
+	case s.highSurrogate:
+		return s.encodingError("unpaired surrogate in \\u escape")
+	}
+	return scanContinue
+}
+
 // stateNeg is the state after reading `-` during a number.
 func stateNeg(s *scanner, c byte) int {
 	if c == '0' {
@@ -673,6 +849,13 @@ func (s *scanner) error(c byte, context string) int {
 	return scanError
 }
 
+// encodingError records a strict mode encoding error and switches to the error state.
+func (s *scanner) encodingError(context string) int {
+	s.step = stateError
+	s.err = &SyntaxError{context, int64(s.offset)}
+	return scanError
+}
+
 // quoteChar formats c as a quoted character literal
 func quoteChar(c byte) string {
 	// special cases - different from quoted strings
@@ -216,6 +216,86 @@ func TestNextValueBig(t *testing.T) {
 	}
 }
 
+func TestSpans(t *testing.T) {
+	alphabet := []byte("ab \t\r\n\"\\\x00\x1f\x7f\x80\xff")
+	for n := 0; n < 1000; n++ {
+		data := make([]byte, rand.Intn(40))
+		for i := range data {
+			if rand.Intn(4) == 0 {
+				data[i] = alphabet[rand.Intn(len(alphabet))]
+			} else {
+				data[i] = 'x'
+			}
+		}
+		for i := 0; i <= len(data); i++ {
+			exp := i
+			for exp < len(data) && data[exp] != '"' && data[exp] != '\\' && data[exp] >= 0x20 {
+				exp++
+			}
+			if got := stringSpan(data, i); got != exp {
+				t.Fatalf("stringSpan(%q, %v) = %v, want %v", data, i, got, exp)
+			}
+			exp = i
+			for exp < len(data) && data[exp] != '"' && data[exp] != '\\' && data[exp] >= 0x20 && data[exp] < 0x80 {
+				exp++
+			}
+			if got := asciiStringSpan(data, i); got != exp {
+				t.Fatalf("asciiStringSpan(%q, %v) = %v, want %v", data, i, got, exp)
+			}
+			exp = i
+			for exp < len(data) && isSpace(data[exp]) {
+				exp++
+			}
+			if got := spaceSpan(data, i); got != exp {
+				t.Fatalf("spaceSpan(%q, %v) = %v, want %v", data, i, got, exp)
+			}
+		}
+	}
+}
+
+// strict mode validation, offsets are those reported by ValidateStrict
+var strictTests = []struct {
+	in     string
+	valid  bool
+	offset int64
+}{
+	{`"abc"`, true, 0},
+	{"\"x\xc3\xa8y\"", true, 0},
+	{`"\ud83d\ude00"`, true, 0},
+	{`["\u0041\u00e8", "\uD83D\uDE00"]`, true, 0},
+	{"\"a\xffb\"", false, 3},
+	{"\"\xc3\"", false, 2},
+	{"\"\xed\xa0\x80\"", false, 2},
+	{"{\"k\xff\": 1}", false, 4},
+	{`"\ud800"`, false, 8},
+	{`"\ud800x"`, false, 8},
+	{`"\ud800\n"`, false, 9},
+	{`"\udc00"`, false, 7},
+	{`"\ud800\ud800"`, false, 13},
+	{`"\ud800\u0041"`, false, 13},
+}
+
+func TestValidateStrict(t *testing.T) {
+	for _, tt := range strictTests {
+		if err := Validate([]byte(tt.in)); err != nil {
+			t.Errorf("Validate(%q) got %v", tt.in, err)
+		}
+		err := ValidateStrict([]byte(tt.in))
+		if tt.valid {
+			if err != nil {
+				t.Errorf("ValidateStrict(%q) got %v", tt.in, err)
+			}
+			continue
+		}
+		se, ok := err.(*SyntaxError)
+		if !ok {
+			t.Errorf("ValidateStrict(%q) expected syntax error, got %v", tt.in, err)
+		} else if se.Offset != tt.offset {
+			t.Errorf("ValidateStrict(%q) expected error at offset %v, got %v (%v)", tt.in, tt.offset, se.Offset, se)
+		}
+	}
+}
+
 var benchScan scanner
 
 func BenchmarkSkipValue(b *testing.B) {
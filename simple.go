@@ -14,6 +14,7 @@ import (
 	"math"
 	"reflect"
 	"strconv"
+	"unicode/utf16"
 	"unicode/utf8"
 )
 
@@ -36,6 +37,16 @@ func SimpleUnmarshalImmutable(data []byte) (interface{}, error) {
 	return unmarshaledValue(&scan)
 }
 
+// as SimpleUnmarshal, but rejects invalid UTF-8 and unpaired UTF-16 surrogates rather than replacing them
+func SimpleUnmarshalStrict(data []byte) (interface{}, error) {
+	var scan scanner
+
+	setScanner(&scan, data)
+	scan.reset()
+	scan.strict = true
+	return unmarshaledValue(&scan)
+}
+
 // this is just to mark that there is no current value
 type unsetType int
 
@@ -206,29 +217,24 @@ func nextLiteral(scan *scanner) ([]byte, error) {
 
 	// first try and see if we can pass the literal straight from our slice
 	start := scan.offset
-	for {
-		if scan.offset >= l {
-			return nil, &SyntaxError{"unexpected end of JSON input", int64(scan.offset)}
-		}
-		c := scan.data[scan.offset]
-
-		// found the other side
-		if c == '"' {
-			scan.step = stateEndValue
-			oldOffset := scan.offset
-			scan.offset++
-			return scan.data[start:oldOffset], nil
-		}
+	scan.offset = asciiStringSpan(scan.data, scan.offset)
+	if scan.offset >= l {
+		return nil, &SyntaxError{"unexpected end of JSON input", int64(scan.offset)}
+	}
+	c := scan.data[scan.offset]
 
-		// no control characters
-		if c < 0x20 {
-			_ = scan.error(c, "in string literal")
-			return nil, scan.err
-		}
-		if c == '\\' || c >= utf8.RuneSelf {
-			break
-		}
+	// found the other side
+	if c == '"' {
+		scan.step = stateEndValue
+		oldOffset := scan.offset
 		scan.offset++
+		return scan.data[start:oldOffset], nil
+	}
+
+	// no control characters
+	if c < 0x20 {
+		_ = scan.error(c, "in string literal")
+		return nil, scan.err
 	}
 
 	usableCap := (scan.offset - start) * 2
@@ -295,6 +301,10 @@ func nextLiteral(scan *scanner) ([]byte, error) {
 					return nil, scan.err
 				}
 				scan.offset = oldOffset + size
+				if scan.strict && size == 6 && utf16.IsSurrogate(getu4(scan.data[oldOffset:])) {
+					_ = scan.encodingError("unpaired surrogate in \\u escape")
+					return nil, scan.err
+				}
 				out += utf8.EncodeRune(literal[out:], rr)
 				continue
 			default:
@@ -303,16 +313,28 @@ func nextLiteral(scan *scanner) ([]byte, error) {
 			}
 			out++
 
-			// ascii
+			// ascii, copied in bulk
 		} else if c < utf8.RuneSelf {
-			literal[out] = c
-			out++
-			scan.offset++
+			end := asciiStringSpan(scan.data, scan.offset+1)
+			n := end - scan.offset
+			if out+n >= usableCap {
+				newCap := (out + n + 8) * 2
+				newLiteral := make([]byte, newCap)
+				copy(newLiteral, literal[:out])
+				literal = newLiteral
+				usableCap = newCap - 8
+			}
+			out += copy(literal[out:], scan.data[scan.offset:end])
+			scan.offset = end
 
 			// UTFs
 		} else {
 			rr, size := utf8.DecodeRune(scan.data[scan.offset:])
 			scan.offset += size
+			if scan.strict && rr == utf8.RuneError && size == 1 {
+				_ = scan.encodingError("invalid UTF-8 in string literal")
+				return nil, scan.err
+			}
 			out += utf8.EncodeRune(literal[out:], rr)
 		}
 	}
@@ -389,7 +411,7 @@ outer:
 
 	f, err := strconv.ParseFloat(src, 64)
 	if err != nil {
-		return nil, &UnmarshalTypeError{"number " + src, reflect.TypeOf(0.0), int64(scan.offset)}
+		return nil, &UnmarshalTypeError{Value: "number " + src, Type: reflect.TypeOf(0.0), Offset: int64(scan.offset)}
 	}
 	return f, nil
 }
@@ -407,6 +429,7 @@ func nextScanValue(scan *scanner) ([]byte, error) {
 	scan = setScanner(scan, scan.data)
 	scan.reset()
 	scan.checkTop = false
+	scan.strict = saveScan.strict
 	scan.offset = saveScan.offset
 
 	// get to beginning of token
@@ -437,8 +460,9 @@ func nextScanValue(scan *scanner) ([]byte, error) {
 					return scan.data[start : i+1], nil
 				}
 			case scanError:
+				err := scan.err
 				*scan = saveScan
-				return nil, scan.err
+				return nil, err
 			case scanEnd:
 				*scan = saveScan
 				return scan.data[start:i], nil
@@ -446,8 +470,9 @@ func nextScanValue(scan *scanner) ([]byte, error) {
 		}
 	}
 	if scan.eof() == scanError {
+		err := scan.err
 		*scan = saveScan
-		return nil, scan.err
+		return nil, err
 	}
 	*scan = saveScan
 	return scan.data[start:], nil
@@ -463,6 +488,7 @@ func nextUnmarshalledValue(scan *scanner) (interface{}, error) {
 	scan.reset()
 	scan.offset = saveScan.offset
 	scan.toString = saveScan.toString
+	scan.strict = saveScan.strict
 
 	// avoid needless stateEndTop error, since we are scanning mid scan
 	scan.checkTop = false
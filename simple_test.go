@@ -14,6 +14,7 @@ package json
 import (
 	"bytes"
 	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -56,6 +57,30 @@ func TestSimpleUnmarshalInterface(t *testing.T) {
 	}
 }
 
+func TestSimpleUnmarshalLongStrings(t *testing.T) {
+	long := strings.Repeat("0123456789abcdef", 20)
+	for _, in := range []string{
+		`"` + long + `"`,
+		`"` + long + `\n` + long + `"`,
+		`"\t` + long + `\u00e8` + long + `\"` + long + `"`,
+		`"` + long + `ü` + long + `\\"`,
+		`{"` + long + `\/": ["` + long + `", "x\u0041` + long + `"]}`,
+	} {
+		exp, err := SimpleUnmarshal([]byte(in))
+		if err != nil {
+			t.Fatalf("SimpleUnmarshal %q: %v", in, err)
+		}
+		var val interface{}
+		err = Unmarshal([]byte(in), &val)
+		if err != nil {
+			t.Fatalf("Unmarshal %q: %v", in, err)
+		}
+		if !reflect.DeepEqual(val, exp) {
+			t.Fatalf("SimpleUnmarshal %q expected %q got %q", in, val, exp)
+		}
+	}
+}
+
 var simpleUnmarshalTests = []unmarshalTest{
 	// basic types
 	{in: `true`, out: true},
@@ -253,6 +278,23 @@ func TestSimpleUnmarshal(t *testing.T) {
 	}
 }
 
+func TestSimpleUnmarshalStrict(t *testing.T) {
+	for _, tt := range strictTests {
+		val, err := SimpleUnmarshalStrict([]byte(tt.in))
+		if tt.valid {
+			if err != nil {
+				t.Fatalf("%q got %v", tt.in, err)
+			}
+			exp, _ := SimpleUnmarshal([]byte(tt.in))
+			if !reflect.DeepEqual(val, exp) {
+				t.Fatalf("%q expected %v, got %v", tt.in, exp, val)
+			}
+		} else if _, ok := err.(*SyntaxError); !ok {
+			t.Fatalf("%q expected syntax error, got %v", tt.in, err)
+		}
+	}
+}
+
 // benchmarks
 
 func BenchmarkSimpleUnmarshal(b *testing.B) {
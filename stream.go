@@ -6,23 +6,41 @@ package json
 
 import (
 	"bytes"
+	"context"
 	"errors"
+	"fmt"
 	"io"
+	"strconv"
+	"strings"
 )
 
 // A Decoder reads and decodes JSON values from an input stream.
 type Decoder struct {
-	r     io.Reader
-	buf   []byte
-	d     decodeState
-	start int // start of unread data in buf
-	scan  scanner
-	err   error
+	r       io.Reader
+	buf     []byte
+	d       decodeState
+	start   int   // start of unread data in buf
+	scanned int64 // amount of data already scanned and dropped from buf
+	scan    scanner
+	err     error
+	maxSize int64 // maximum size of a value, if positive
 
 	tokenState int
 	tokenStack []int
 }
 
+// A ValueTooLargeError is returned by a Decoder for a value larger than the
+// maximum set by SetMaxValueSize.
+type ValueTooLargeError struct {
+	Offset int64 // offset of the value in the input stream
+	Limit  int64 // the maximum size
+}
+
+func (e *ValueTooLargeError) Error() string {
+	return "json: value at offset " + strconv.FormatInt(e.Offset, 10) +
+		" exceeds the maximum size of " + strconv.FormatInt(e.Limit, 10) + " bytes"
+}
+
 // NewDecoder returns a new decoder that reads from r.
 //
 // The decoder introduces its own buffering and may
@@ -40,49 +58,242 @@ func NewDecoder(r io.Reader) *Decoder {
 // Number instead of as a float64.
 func (dec *Decoder) UseNumber() { dec.d.useNumber = true }
 
+// DisallowUnknownFields causes the Decoder to return an UnknownFieldError when
+// the destination is a struct and the input contains object keys which do not
+// match any non-ignored, exported fields in the destination.
+func (dec *Decoder) DisallowUnknownFields() { dec.d.opts.DisallowUnknownFields = true }
+
+// UseCaseSensitiveKeys causes the Decoder to only match object keys to struct
+// fields with exactly the same name, rather than also accepting keys which only
+// differ in case.
+func (dec *Decoder) UseCaseSensitiveKeys() { dec.d.opts.CaseSensitive = true }
+
+// SetRegistry causes the Decoder to use the decoders registered in r.
+func (dec *Decoder) SetRegistry(r *Registry) { dec.d.opts.Registry = r }
+
+// SetMaxValueSize limits the size of the values the Decoder reads, not counting
+// the white space preceding them, to n bytes, or removes the limit if n is not
+// positive. Reading a larger value fails with a ValueTooLargeError, which leaves
+// the value unread, so that it can be discarded with Skip, and decoding can go on
+// with the next value.
+func (dec *Decoder) SetMaxValueSize(n int64) { dec.maxSize = n }
+
+// SetContext sets the context passed to the values implementing UnmarshalerContext.
+func (dec *Decoder) SetContext(ctx context.Context) { dec.d.opts.Context = ctx }
+
 // Decode reads the next JSON-encoded value from its
 // input and stores it in the value pointed to by v.
 //
 // See the documentation for Unmarshal for details about
 // the conversion of JSON into a Go value.
 func (dec *Decoder) Decode(v interface{}) error {
+	_, _, err := dec.DecodeWithSpan(v)
+	return err
+}
+
+// DecodeWithSpan is like Decode, but also returns the offsets in the input
+// stream of the first byte of the value and of the byte following it, so that
+// the value can be read again from there.
+// If no value could be read, both offsets are the current InputOffset.
+func (dec *Decoder) DecodeWithSpan(v interface{}) (start, end int64, err error) {
+	data, err := dec.rawValue(false)
+	if err != nil {
+		return dec.InputOffset(), dec.InputOffset(), err
+	}
+	end = dec.InputOffset()
+	start = end - int64(len(data)-spaceSpan(data, 0))
+	dec.d.init(data)
+
+	// Don't save err from unmarshal into dec.err:
+	// the connection is still usable since we read a complete JSON
+	// object from it before the error happened.
+	err = dec.d.unmarshal(v)
+	return start, end, err
+}
+
+// rawValue reads the next value in the stream, including any white space
+// preceding it, and returns it without decoding it.
+// The slice is only valid until the next read from the stream.
+// With discard set, the value is dropped from the buffer as it is read,
+// regardless of its size, and only its tail is returned.
+func (dec *Decoder) rawValue(discard bool) ([]byte, error) {
 	if dec.err != nil {
-		return dec.err
+		return nil, dec.err
 	}
 
 	if err := dec.tokenPrepareForDecode(); err != nil {
-		return err
+		return nil, err
 	}
 
 	if !dec.tokenValueAllowed() {
-		return &SyntaxError{msg: "not at beginning of value"}
+		return nil, &SyntaxError{msg: "not at beginning of value"}
 	}
 
 	// Read whole value into buffer.
-	n, err := dec.readValue()
+	n, err := dec.readValue(discard)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	// we should have a scanner buffer by now
 	if dec.scan.data == nil {
-		return errors.New("Uninitialized scanner buffer")
+		return nil, errors.New("Uninitialized scanner buffer")
 	}
 
-	dec.d.init(dec.scan.data[dec.start : dec.start+n])
+	data := dec.scan.data[dec.start : dec.start+n]
 	dec.start += n
 
-	// Don't save err from unmarshal into dec.err:
-	// the connection is still usable since we read a complete JSON
-	// object from it before the error happened.
-	err = dec.d.unmarshal(v)
-
 	// fixup token streaming state
 	dec.tokenValueEnd()
+	return data, nil
+}
 
+// Skip reads the next JSON value from its input and discards it,
+// without decoding it, and without buffering it whole, so that it can be used
+// to skip a value larger than the maximum set by SetMaxValueSize.
+func (dec *Decoder) Skip() error {
+	_, err := dec.rawValue(true)
 	return err
 }
 
+// DecodeEach reads the next JSON value from its input, and calls fn in turn
+// with each element of the array found in it at pointer, a JSON Pointer such
+// as "/results", skipping the rest of the value without decoding it.
+// The slice passed to fn is only valid until fn returns.
+// If there is nothing at pointer, fn is not called; if the value there is not
+// an array, an error is returned.
+// If fn returns an error, DecodeEach stops calling it and returns the error.
+// In both cases the rest of the value is skipped first, so that the Decoder
+// can go on with the next value.
+func (dec *Decoder) DecodeEach(pointer string, fn func(raw []byte) error) error {
+	var open []Delim
+	var path []string
+	var stop error // returned once the rest of the value is skipped
+
+	if pointer != "" {
+		path = parsePointer(pointer)
+	}
+	found := true
+	for _, p := range path {
+		tok, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		d, ok := tok.(Delim)
+		if !ok {
+			found = false
+			break
+		}
+		open = append(open, d)
+		if d == '{' {
+			found, err = dec.seekKey(p)
+		} else {
+			found, err = dec.seekIndex(p)
+		}
+		if err != nil {
+			return err
+		}
+		if !found {
+			break
+		}
+	}
+
+	if found {
+		if err := dec.tokenPrepareForDecode(); err != nil {
+			return err
+		}
+		c, err := dec.peek()
+		if err != nil {
+			return err
+		}
+		if c != '[' {
+			stop = fmt.Errorf("json: value at %q is not an array", pointer)
+			if err = dec.Skip(); err != nil {
+				return err
+			}
+		} else {
+			if _, err = dec.Token(); err != nil {
+				return err
+			}
+			for dec.More() {
+				if stop != nil {
+					if err = dec.Skip(); err != nil {
+						return err
+					}
+					continue
+				}
+				raw, err := dec.rawValue(false)
+				if err != nil {
+					return err
+				}
+				stop = fn(raw[spaceSpan(raw, 0):])
+			}
+			if _, err = dec.Token(); err != nil {
+				return err
+			}
+		}
+	}
+
+	// skip the rest of the enclosing objects and arrays
+	for i := len(open) - 1; i >= 0; i-- {
+		for dec.More() {
+			if open[i] == '{' {
+				if _, err := dec.Token(); err != nil {
+					return err
+				}
+			}
+			if err := dec.Skip(); err != nil {
+				return err
+			}
+		}
+		if _, err := dec.Token(); err != nil {
+			return err
+		}
+	}
+	return stop
+}
+
+// seekKey skips the members of the current object up to the value of key
+func (dec *Decoder) seekKey(key string) (bool, error) {
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return false, err
+		}
+		if tok == key {
+			return true, nil
+		}
+		if err = dec.Skip(); err != nil {
+			return false, err
+		}
+	}
+	return false, nil
+}
+
+// seekIndex skips the elements of the current array up to the one at index
+func (dec *Decoder) seekIndex(index string) (bool, error) {
+	i, err := strconv.Atoi(index)
+	if err != nil || i < 0 {
+		return false, nil
+	}
+	for n := 0; dec.More(); n++ {
+		if n == i {
+			return true, nil
+		}
+		if err = dec.Skip(); err != nil {
+			return false, err
+		}
+	}
+	return false, nil
+}
+
+// InputOffset returns the offset in the input stream of the decoder's current
+// position: the end of the last value or token returned, and the start of the
+// space preceding the next one.
+func (dec *Decoder) InputOffset() int64 {
+	return dec.scanned + int64(dec.start)
+}
+
 // Buffered returns a reader of the data remaining in the Decoder's
 // buffer. The reader is valid until the next call to Decode.
 func (dec *Decoder) Buffered() io.Reader {
@@ -94,7 +305,8 @@ func (dec *Decoder) Buffered() io.Reader {
 
 // readValue reads a JSON value into the scanner data buffer
 // It returns the length of the encoding.
-func (dec *Decoder) readValue() (int, error) {
+// With discard set, the data scanned is dropped at each refill.
+func (dec *Decoder) readValue(discard bool) (int, error) {
 	scan := &dec.scan
 	scan.reset()
 	scan.offset = dec.start
@@ -142,19 +354,42 @@ Input:
 			return 0, err
 		}
 
+		if discard {
+			dec.start = scan.offset
+		} else if dec.maxSize > 0 {
+
+			// leading white space does not count, and is not kept
+			dec.start = spaceSpan(scan.data, dec.start)
+			if int64(len(scan.data)-dec.start) > dec.maxSize {
+				return 0, dec.valueTooLarge(dec.start)
+			}
+		}
+
 		var adjust int
 		adjust, err = dec.refill()
 		start -= adjust
 	}
+	if !discard && dec.maxSize > 0 {
+		valueStart := spaceSpan(scan.data, dec.start)
+		if int64(start-valueStart) > dec.maxSize {
+			return 0, dec.valueTooLarge(valueStart)
+		}
+	}
 	return start - dec.start, nil
 }
 
+// valueTooLarge returns the error for a value starting at offset in the buffer
+func (dec *Decoder) valueTooLarge(offset int) error {
+	return &ValueTooLargeError{Offset: dec.scanned + int64(offset), Limit: dec.maxSize}
+}
+
 func (dec *Decoder) refill() (int, error) {
 	adjust := 0
 
 	// Make room to read more into the buffer.
 	// First slide down data already consumed.
 	if dec.start > 0 {
+		dec.scanned += int64(dec.start)
 		n := copy(dec.scan.data, dec.scan.data[dec.start:])
 		dec.scan.data = dec.scan.data[:n]
 		adjust = dec.start
@@ -201,18 +436,21 @@ func nonSpace(b []byte) bool {
 
 // An Encoder writes JSON values to an output stream.
 type Encoder struct {
-	w          io.Writer
-	err        error
-	escapeHTML bool
+	w    io.Writer
+	err  error
+	opts EncodeOptions
+
+	indentBuf *bytes.Buffer
 
-	indentBuf    *bytes.Buffer
-	indentPrefix string
-	indentValue  string
+	// EncodeToken state, and output not yet written
+	tokenState int
+	tokenStack []int
+	tokenBuf   encodeState
 }
 
 // NewEncoder returns a new encoder that writes to w.
 func NewEncoder(w io.Writer) *Encoder {
-	return &Encoder{w: w, escapeHTML: true}
+	return &Encoder{w: w, opts: DefaultEncodeOptions()}
 }
 
 // Encode writes the JSON encoding of v to the stream,
@@ -221,17 +459,34 @@ func NewEncoder(w io.Writer) *Encoder {
 // See the documentation for Marshal for details about the
 // conversion of Go values to JSON.
 func (enc *Encoder) Encode(v interface{}) error {
-	var eS encodeState
+	eS := encodeState{ctx: enc.opts.Context}
 
 	e := &eS
 	if enc.err != nil {
 		return enc.err
 	}
-	err := e.marshal(v, encOpts{escapeHTML: enc.escapeHTML})
+	opts, err := enc.opts.encOpts()
+	if err != nil {
+		return err
+	}
+	err = e.marshal(v, opts)
 	if err != nil {
 		return err
 	}
 
+	// a value within arrays and objects started by EncodeToken
+	if enc.tokenState != tokenTopValue {
+		if err = enc.tokenPrepareForValue(); err != nil {
+			return err
+		}
+		if enc.opts.Prefix == "" && enc.opts.Indent == "" {
+			enc.tokenBuf.Write(e.Bytes())
+		} else if err = enc.tokenWrite(e.Bytes()); err != nil {
+			return err
+		}
+		return enc.tokenValueEnd()
+	}
+
 	// Terminate each value with a newline.
 	// This makes the output look a little nicer
 	// when debugging, and some kind of space
@@ -241,12 +496,12 @@ func (enc *Encoder) Encode(v interface{}) error {
 	e.WriteByte('\n')
 
 	b := e.Bytes()
-	if enc.indentPrefix != "" || enc.indentValue != "" {
+	if enc.opts.Prefix != "" || enc.opts.Indent != "" {
 		if enc.indentBuf == nil {
 			enc.indentBuf = new(bytes.Buffer)
 		}
 		enc.indentBuf.Reset()
-		err = Indent(enc.indentBuf, b, enc.indentPrefix, enc.indentValue)
+		err = Indent(enc.indentBuf, b, enc.opts.Prefix, enc.opts.Indent)
 		if err != nil {
 			return err
 		}
@@ -262,8 +517,8 @@ func (enc *Encoder) Encode(v interface{}) error {
 // value as if indented by the package-level function Indent(dst, src, prefix, indent).
 // Calling SetIndent("", "") disables indentation.
 func (enc *Encoder) SetIndent(prefix, indent string) {
-	enc.indentPrefix = prefix
-	enc.indentValue = indent
+	enc.opts.Prefix = prefix
+	enc.opts.Indent = indent
 }
 
 // SetEscapeHTML specifies whether problematic HTML characters
@@ -274,7 +529,156 @@ func (enc *Encoder) SetIndent(prefix, indent string) {
 // In non-HTML settings where the escaping interferes with the readability
 // of the output, SetEscapeHTML(false) disables this behavior.
 func (enc *Encoder) SetEscapeHTML(on bool) {
-	enc.escapeHTML = on
+	enc.opts.EscapeHTML = on
+}
+
+// SetSortMapKeys specifies whether map keys are written in sorted order,
+// which is the default, or in map iteration order, which is faster.
+func (enc *Encoder) SetSortMapKeys(on bool) {
+	enc.opts.SortMapKeys = on
+}
+
+// SetOptions replaces all the encoding options, including those set by
+// SetIndent and SetEscapeHTML, for subsequent encoded values.
+func (enc *Encoder) SetOptions(opts EncodeOptions) {
+	enc.opts = opts
+}
+
+// SetContext sets the context passed to the values implementing MarshalerContext.
+func (enc *Encoder) SetContext(ctx context.Context) {
+	enc.opts.Context = ctx
+}
+
+// recordSeparator starts each JSON text in a JSON text sequence
+const recordSeparator = 0x1E
+
+// A SeqDecoder reads a JSON text sequence (RFC 7464, application/json-seq)
+// from an input stream, where each JSON text is preceded by an ASCII record
+// separator and followed by a line feed.
+type SeqDecoder struct {
+	dec  *Decoder
+	scan scanner
+}
+
+// A TruncatedRecordError is returned by a SeqDecoder for a record which does not
+// hold a complete JSON text, as is the case when a writer was interrupted.
+// Decoding can continue with the next record.
+type TruncatedRecordError struct {
+	Offset int64 // offset of the record's text in the stream
+	Err    error // the syntax error, with offsets relative to the record
+}
+
+func (e *TruncatedRecordError) Error() string {
+	return "json: truncated record at offset " + strconv.FormatInt(e.Offset, 10) + ": " + e.Err.Error()
+}
+
+func (e *TruncatedRecordError) Unwrap() error { return e.Err }
+
+// NewSeqDecoder returns a new decoder of the JSON text sequence in r.
+//
+// Like a Decoder, the SeqDecoder introduces its own buffering and may
+// read data from r beyond the JSON values requested.
+func NewSeqDecoder(r io.Reader) *SeqDecoder {
+	sd := &SeqDecoder{dec: NewDecoder(r)}
+	setScanner(&sd.scan, nil)
+	return sd
+}
+
+// SetOptions sets the options used to decode each record.
+// SinglePass is ignored, since each record is validated before it is decoded.
+func (sd *SeqDecoder) SetOptions(opts DecodeOptions) {
+	sd.dec.d.opts = opts
+}
+
+// Decode reads the next JSON text in the sequence and stores it in the value
+// pointed to by v. Empty records are skipped.
+// As RFC 7464 prescribes, a record which is not a valid JSON text, or which
+// holds a number, true, false or null not followed by white space, is taken to
+// be truncated and is reported as a TruncatedRecordError, after which
+// decoding resumes at the next record separator.
+// It returns io.EOF at the end of the input.
+//
+// See the documentation for Unmarshal for details about the
+// conversion of JSON into a Go value.
+func (sd *SeqDecoder) Decode(v interface{}) error {
+	for {
+		offset := sd.dec.InputOffset()
+		rec, err := sd.dec.readUntil(recordSeparator)
+		if err != nil {
+			return err
+		}
+		if !nonSpace(rec) {
+			continue
+		}
+
+		// keep the parse state allocated by previous records
+		ps := sd.scan.parseState
+		setScanner(&sd.scan, rec)
+		sd.scan.parseState = ps
+		err = checkValid(rec, &sd.scan)
+		if err == nil && !seqTerminated(rec) {
+			err = &SyntaxError{msg: "top-level value not followed by white space", Offset: int64(len(rec))}
+		}
+		if err != nil {
+			return &TruncatedRecordError{Offset: offset, Err: err}
+		}
+		return sd.dec.d.init(rec).unmarshal(v)
+	}
+}
+
+// seqTerminated checks that a valid record holding a number or a literal
+// is followed by white space, so that it cannot have been cut short
+func seqTerminated(rec []byte) bool {
+	for _, c := range rec {
+		if isSpace(c) {
+			continue
+		}
+		if c == '{' || c == '[' || c == '"' {
+			return true
+		}
+		break
+	}
+	return isSpace(rec[len(rec)-1])
+}
+
+// A SeqEncoder writes a JSON text sequence (RFC 7464, application/json-seq)
+// to an output stream.
+type SeqEncoder struct {
+	enc *Encoder
+}
+
+// seqWriter prefixes each value written by an Encoder with a record separator,
+// writing the whole record at once
+type seqWriter struct {
+	w   io.Writer
+	buf []byte
+}
+
+func (sw *seqWriter) Write(p []byte) (int, error) {
+	sw.buf = append(append(sw.buf[:0], recordSeparator), p...)
+	if _, err := sw.w.Write(sw.buf); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// NewSeqEncoder returns a new encoder of a JSON text sequence to w.
+func NewSeqEncoder(w io.Writer) *SeqEncoder {
+	return &SeqEncoder{enc: NewEncoder(&seqWriter{w: w})}
+}
+
+// SetOptions replaces all the encoding options.
+func (se *SeqEncoder) SetOptions(opts EncodeOptions) {
+	se.enc.SetOptions(opts)
+}
+
+// Encode writes the JSON encoding of v to the stream as a record, preceded
+// by a record separator and followed by a line feed.
+//
+// See the documentation for Marshal for details about the
+// conversion of Go values to JSON.
+func (se *SeqEncoder) Encode(v interface{}) error {
+	return se.enc.Encode(v)
 }
 
 // RawMessage is a raw encoded JSON value.
@@ -534,19 +938,210 @@ func (dec *Decoder) peek() (byte, error) {
 	}
 }
 
-/*
-TODO
+// readUntil returns the data up to the next delim byte in the decoder's input,
+// consuming the delimiter.
+// At the end of the input, it returns any data left before reporting io.EOF.
+func (dec *Decoder) readUntil(delim byte) ([]byte, error) {
+	if dec.err != nil {
+		return nil, dec.err
+	}
+
+	var err error
+
+	from := dec.start
+	for {
+		if i := bytes.IndexByte(dec.scan.data[from:], delim); i >= 0 {
+			data := dec.scan.data[dec.start : from+i]
+			dec.start = from + i + 1
+			return data, nil
+		}
+		from = len(dec.scan.data)
+
+		// buffer has been searched, now report any error
+		if err != nil {
+			if err == io.EOF && dec.start < len(dec.scan.data) {
+				data := dec.scan.data[dec.start:]
+				dec.start = len(dec.scan.data)
+				return data, nil
+			}
+			dec.err = err
+			return nil, err
+		}
+		var adjust int
+		adjust, err = dec.refill()
+		from -= adjust
+	}
+}
 
 // EncodeToken writes the given JSON token to the stream.
-// It returns an error if the delimiters [ ] { } are not properly used.
+// Tokens are Delim values, for the four JSON delimiters [ ] { },
+// strings, numbers, bools and nil, as returned by Decoder.Token,
+// and RawMessage values, which are validated and copied as they are.
+// Strings following '{' and complete object members are written as keys.
+// It returns an error if the delimiters are not properly nested,
+// or if an object key or value is not where one is expected.
+// Encode can be called to write whole values where EncodeToken writes scalars.
 //
-// EncodeToken does not call Flush, because usually it is part of
-// a larger operation such as Encode, and those will call Flush when finished.
-// Callers that create an Encoder and then invoke EncodeToken directly,
-// without using Encode, need to call Flush when finished to ensure that
-// the JSON is written to the underlying writer.
-func (e *Encoder) EncodeToken(t Token) error  {
-	...
+// Output is buffered until a top level value is complete, or the buffer
+// grows large; callers writing partial values must call Flush
+// to write the data to the underlying writer.
+func (enc *Encoder) EncodeToken(t Token) error {
+	if enc.err != nil {
+		return enc.err
+	}
+	opts, err := enc.opts.encOpts()
+	if err != nil {
+		return err
+	}
+	switch tt := t.(type) {
+	case Delim:
+		switch tt {
+		case '[', '{':
+			if err = enc.tokenPrepareForValue(); err != nil {
+				return err
+			}
+			enc.tokenBuf.WriteByte(byte(tt))
+			enc.tokenStack = append(enc.tokenStack, enc.tokenState)
+			if tt == '[' {
+				enc.tokenState = tokenArrayStart
+			} else {
+				enc.tokenState = tokenObjectStart
+			}
+			return enc.tokenFlushIfFull()
+		case ']', '}':
+			start, comma := tokenArrayStart, tokenArrayComma
+			if tt == '}' {
+				start, comma = tokenObjectStart, tokenObjectComma
+			}
+			if enc.tokenState != start && enc.tokenState != comma {
+				return fmt.Errorf("json: unexpected delimiter %v", tt)
+			}
+			n := len(enc.tokenStack) - 1
+			if enc.tokenState == comma {
+				enc.tokenNewline(n)
+			}
+			enc.tokenBuf.WriteByte(byte(tt))
+			enc.tokenState = enc.tokenStack[n]
+			enc.tokenStack = enc.tokenStack[:n]
+			return enc.tokenValueEnd()
+		}
+		return fmt.Errorf("json: invalid delimiter %v", tt)
+	case string:
+		if enc.tokenState == tokenObjectStart || enc.tokenState == tokenObjectComma {
+			if enc.tokenState == tokenObjectComma {
+				enc.tokenBuf.WriteByte(',')
+			}
+			enc.tokenNewline(len(enc.tokenStack))
+			enc.tokenBuf.string(tt, opts)
+			enc.tokenBuf.WriteByte(':')
+			if enc.opts.Prefix != "" || enc.opts.Indent != "" {
+				enc.tokenBuf.WriteByte(' ')
+			}
+			enc.tokenState = tokenObjectValue
+			return enc.tokenFlushIfFull()
+		}
+	case RawMessage:
+		if err = enc.tokenPrepareForValue(); err != nil {
+			return err
+		}
+		if err = enc.tokenWrite(tt); err != nil {
+			return err
+		}
+		return enc.tokenValueEnd()
+	case nil, bool, Number, float32, float64,
+		int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+	default:
+		return fmt.Errorf("json: invalid token type %T", t)
+	}
+
+	// scalars
+	if err = enc.tokenPrepareForValue(); err != nil {
+		return err
+	}
+	len0 := enc.tokenBuf.Len()
+	enc.tokenBuf.ctx = enc.opts.Context
+	if err = enc.tokenBuf.marshal(t, opts); err != nil {
+		enc.tokenBuf.Truncate(len0)
+		return err
+	}
+	return enc.tokenValueEnd()
 }
 
-*/
+// Flush writes the output buffered by EncodeToken to the underlying writer.
+func (enc *Encoder) Flush() error {
+	if enc.err != nil {
+		return enc.err
+	}
+	if enc.tokenBuf.Len() == 0 {
+		return nil
+	}
+	_, err := enc.w.Write(enc.tokenBuf.Bytes())
+	enc.tokenBuf.Reset()
+	if err != nil {
+		enc.err = err
+	}
+	return err
+}
+
+// flush when this much output is buffered by EncodeToken
+const tokenFlushSize = 4096
+
+func (enc *Encoder) tokenFlushIfFull() error {
+	if enc.tokenBuf.Len() >= tokenFlushSize {
+		return enc.Flush()
+	}
+	return nil
+}
+
+// tokenPrepareForValue writes the separator preceding a value, if one is allowed
+func (enc *Encoder) tokenPrepareForValue() error {
+	switch enc.tokenState {
+	case tokenTopValue, tokenObjectValue:
+	case tokenArrayComma:
+		enc.tokenBuf.WriteByte(',')
+		fallthrough
+	case tokenArrayStart:
+		enc.tokenNewline(len(enc.tokenStack))
+	default:
+		return errors.New("json: expected object key")
+	}
+	return nil
+}
+
+// tokenValueEnd updates the state after a value, terminating top level values as Encode does
+func (enc *Encoder) tokenValueEnd() error {
+	switch enc.tokenState {
+	case tokenArrayStart:
+		enc.tokenState = tokenArrayComma
+	case tokenObjectValue:
+		enc.tokenState = tokenObjectComma
+	case tokenTopValue:
+		enc.tokenBuf.WriteByte('\n')
+		return enc.Flush()
+	}
+	return enc.tokenFlushIfFull()
+}
+
+// tokenNewline starts a new line at depth, if indenting
+func (enc *Encoder) tokenNewline(depth int) {
+	if enc.opts.Prefix != "" || enc.opts.Indent != "" {
+		newline(&enc.tokenBuf.Buffer, enc.opts.Prefix, enc.opts.Indent, depth)
+	}
+}
+
+// tokenWrite validates and copies an encoded value, indenting it to the current depth
+func (enc *Encoder) tokenWrite(b []byte) error {
+	var err error
+
+	len0 := enc.tokenBuf.Len()
+	if enc.opts.Prefix != "" || enc.opts.Indent != "" {
+		prefix := enc.opts.Prefix + strings.Repeat(enc.opts.Indent, len(enc.tokenStack))
+		err = Indent(&enc.tokenBuf.Buffer, b, prefix, enc.opts.Indent)
+	} else {
+		err = compact(&enc.tokenBuf.Buffer, b, enc.opts.EscapeHTML)
+	}
+	if err != nil {
+		enc.tokenBuf.Truncate(len0)
+	}
+	return err
+}
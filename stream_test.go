@@ -6,6 +6,8 @@ package json
 
 import (
 	"bytes"
+	"errors"
+	"fmt"
 	"io"
 	"io/ioutil"
 	"log"
@@ -15,6 +17,7 @@ import (
 	"reflect"
 	"strings"
 	"testing"
+	"testing/iotest"
 )
 
 // Test values for the stream test.
@@ -191,6 +194,256 @@ func nlines(s string, n int) string {
 	return s
 }
 
+func TestDecoderInputOffset(t *testing.T) {
+	var in bytes.Buffer
+	for i := 0; i < 200; i++ {
+		fmt.Fprintf(&in, "{\"n\": %d, \"pad\": %q}\n  ", i, strings.Repeat("x", i%37))
+	}
+	in.WriteString(`"last"`)
+	data := in.Bytes()
+
+	for _, r := range []io.Reader{bytes.NewReader(data), iotest.OneByteReader(bytes.NewReader(data))} {
+		dec := NewDecoder(r)
+		for i := 0; ; i++ {
+			var v interface{}
+			start, end, err := dec.DecodeWithSpan(&v)
+			if err == io.EOF {
+				if start != int64(len(data)) || end != start {
+					t.Errorf("at EOF got span %v %v", start, end)
+				}
+				break
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if end != dec.InputOffset() {
+				t.Errorf("#%d: end %v, InputOffset %v", i, end, dec.InputOffset())
+			}
+
+			// the span reads back as the same value
+			var back interface{}
+			if err := Unmarshal(data[start:end], &back); err != nil || !reflect.DeepEqual(back, v) {
+				t.Fatalf("#%d: span %v %v holds %q", i, start, end, data[start:end])
+			}
+			if isSpace(data[start]) || (i < 200 && data[end-1] != '}') {
+				t.Fatalf("#%d: span %v %v holds %q", i, start, end, data[start:end])
+			}
+		}
+	}
+
+	// tokens move the offset too
+	dec := NewDecoder(strings.NewReader(` [1, {"a": 2}] `))
+	for _, want := range []int64{2, 3} {
+		if _, err := dec.Token(); err != nil || dec.InputOffset() != want {
+			t.Errorf("expected offset %v got %v, %v", want, dec.InputOffset(), err)
+		}
+	}
+	var v interface{}
+	if start, end, err := dec.DecodeWithSpan(&v); start != 5 || end != 13 || err != nil {
+		t.Errorf("expected span 5 13 got %v %v, %v", start, end, err)
+	}
+	if _, err := dec.Token(); err != nil || dec.InputOffset() != 14 {
+		t.Errorf("expected offset 14 got %v, %v", dec.InputOffset(), err)
+	}
+}
+
+func TestSeq(t *testing.T) {
+	var buf bytes.Buffer
+
+	enc := NewSeqEncoder(&buf)
+	for _, v := range streamTest {
+		if err := enc.Encode(v); err != nil {
+			t.Fatalf("Encode got %v", err)
+		}
+	}
+	if !bytes.HasPrefix(buf.Bytes(), []byte("\x1e0.1\n\x1e\"hello\"\n\x1enull\n")) {
+		t.Fatalf("unexpected sequence %q", buf.Bytes())
+	}
+
+	// write a truncated record in the middle
+	data := buf.Bytes()
+	i := bytes.IndexByte(data, '[')
+	in := string(data[:i]) + "\x1e[\"a\", \"b\x1e\x1e\x1e12\x1etrue " + string(data[i-1:])
+	for _, r := range []io.Reader{strings.NewReader(in), iotest.OneByteReader(strings.NewReader(in))} {
+		var got []interface{}
+		var truncated []string
+
+		dec := NewSeqDecoder(r)
+		dec.SetOptions(DecodeOptions{})
+		for {
+			var v interface{}
+
+			err := dec.Decode(&v)
+			if err == io.EOF {
+				break
+			}
+			if terr, ok := err.(*TruncatedRecordError); ok {
+				truncated = append(truncated, in[terr.Offset:terr.Offset+4])
+				continue
+			}
+			if err != nil {
+				t.Fatalf("Decode got %v", err)
+			}
+			got = append(got, v)
+		}
+		expected := append(append([]interface{}{}, streamTest[:5]...), true)
+		expected = append(expected, streamTest[5:]...)
+		if !reflect.DeepEqual(got, expected) {
+			t.Fatalf("expected %v got %v", expected, got)
+		}
+		if !reflect.DeepEqual(truncated, []string{"[\"a\"", "12\x1et"}) {
+			t.Fatalf("unexpected truncated records %q", truncated)
+		}
+	}
+}
+
+func TestDecoderSkip(t *testing.T) {
+	dec := NewDecoder(strings.NewReader(` {"a": [1, {"b": "}"}]} "s" [1, 2] 3`))
+	if err := dec.Skip(); err != nil {
+		t.Fatalf("Skip got %v", err)
+	}
+	var s string
+	if err := dec.Decode(&s); err != nil || s != "s" {
+		t.Fatalf("expected s, got %q %v", s, err)
+	}
+
+	// skip within an array
+	if tok, err := dec.Token(); err != nil || tok != Delim('[') {
+		t.Fatalf("expected [, got %v %v", tok, err)
+	}
+	if err := dec.Skip(); err != nil {
+		t.Fatalf("Skip got %v", err)
+	}
+	var n int
+	if err := dec.Decode(&n); err != nil || n != 2 {
+		t.Fatalf("expected 2, got %v %v", n, err)
+	}
+	if tok, err := dec.Token(); err != nil || tok != Delim(']') {
+		t.Fatalf("expected ], got %v %v", tok, err)
+	}
+	if err := dec.Skip(); err != nil {
+		t.Fatalf("Skip got %v", err)
+	}
+	if err := dec.Skip(); err != io.EOF {
+		t.Fatalf("expected EOF, got %v", err)
+	}
+}
+
+func TestDecodeEach(t *testing.T) {
+	const in = `{"meta": {"results": [0]}, "results": [{"a": 1}, [2], "3" ,4], "more": [5]}
+		{"x": [{"a/b": [[], [6, 7]]}]}
+		[8, 9]
+		{"results": 10}
+		{"results": 11}
+		{"results": 12}`
+
+	for _, r := range []io.Reader{strings.NewReader(in), iotest.OneByteReader(strings.NewReader(in))} {
+		var got []string
+
+		dec := NewDecoder(r)
+		each := func(raw []byte) error {
+			got = append(got, string(raw))
+			return nil
+		}
+		for _, pointer := range []string{"/results", "/x/0/a~1b/1", "", "/missing"} {
+			if err := dec.DecodeEach(pointer, each); err != nil {
+				t.Fatalf("%q got %v", pointer, err)
+			}
+		}
+		expected := []string{`{"a": 1}`, `[2]`, `"3"`, `4`, `6`, `7`, `8`, `9`}
+		if !reflect.DeepEqual(got, expected) {
+			t.Fatalf("expected %q got %q", expected, got)
+		}
+		if err := dec.DecodeEach("/results", each); err == nil {
+			t.Fatal("expected error for a value which is not an array")
+		}
+
+		// the value in error is skipped
+		var v map[string]int
+		if err := dec.Decode(&v); err != nil || v["results"] != 12 {
+			t.Fatalf("expected the next value, got %v %v", v, err)
+		}
+	}
+
+	// the decoder continues after the value
+	dec := NewDecoder(strings.NewReader(`{"a": [1, 2], "b": {"c": [3]}} true`))
+	stop := errors.New("stop")
+	if err := dec.DecodeEach("/b/c", func([]byte) error { return nil }); err != nil {
+		t.Fatalf("DecodeEach got %v", err)
+	}
+	var b bool
+	if err := dec.Decode(&b); err != nil || !b {
+		t.Fatalf("expected true, got %v %v", b, err)
+	}
+
+	dec = NewDecoder(strings.NewReader(`{"a": [[1], {"b": 2}, 3], "c": [4]} [5]`))
+	count := 0
+	err := dec.DecodeEach("/a", func([]byte) error {
+		count++
+		return stop
+	})
+	if err != stop || count != 1 {
+		t.Fatalf("expected stop after 1 element, got %v after %v", err, count)
+	}
+
+	// so is the rest of the value after an error from fn
+	var a []int
+	if err := dec.Decode(&a); err != nil || !reflect.DeepEqual(a, []int{5}) {
+		t.Fatalf("expected [5], got %v %v", a, err)
+	}
+}
+
+func TestDecoderMaxValueSize(t *testing.T) {
+	big := "[" + strings.Repeat("\"abcdefgh\", ", 100000) + "1]"
+	in := `{"a": "small"} ` + big + strings.Repeat(" ", 100000) + `12 "0123456789" {"b": "too long"}`
+	bigOffset := int64(strings.Index(in, "["))
+
+	for _, r := range []io.Reader{strings.NewReader(in), iotest.OneByteReader(strings.NewReader(in))} {
+		var v interface{}
+
+		dec := NewDecoder(r)
+		dec.SetMaxValueSize(14)
+		if err := dec.Decode(&v); err != nil {
+			t.Fatalf("Decode got %v", err)
+		}
+
+		// the error leaves the value unread
+		for i := 0; i < 2; i++ {
+			err := dec.Decode(&v)
+			terr, ok := err.(*ValueTooLargeError)
+			if !ok || terr.Offset != bigOffset || terr.Limit != 14 {
+				t.Fatalf("expected ValueTooLargeError at %v, got %v", bigOffset, err)
+			}
+			if cap(dec.scan.data) > 64*1024 {
+				t.Fatalf("buffer grew to %v", cap(dec.scan.data))
+			}
+		}
+		if err := dec.Skip(); err != nil {
+			t.Fatalf("Skip got %v", err)
+		}
+		if dec.InputOffset() != bigOffset+int64(len(big)) {
+			t.Fatalf("expected offset %v after Skip, got %v", bigOffset+int64(len(big)), dec.InputOffset())
+		}
+		for _, expected := range []interface{}{int64(12), "0123456789"} {
+			if err := dec.Decode(&v); err != nil || v != expected {
+				t.Fatalf("expected %v got %v %v", expected, v, err)
+			}
+		}
+		if cap(dec.scan.data) > 64*1024 {
+			t.Fatalf("buffer grew to %v", cap(dec.scan.data))
+		}
+		if _, ok := dec.Decode(&v).(*ValueTooLargeError); !ok {
+			t.Fatal("expected ValueTooLargeError for a complete value")
+		}
+		if err := dec.Skip(); err != nil {
+			t.Fatalf("Skip got %v", err)
+		}
+		if err := dec.Decode(&v); err != io.EOF {
+			t.Fatalf("expected EOF, got %v", err)
+		}
+	}
+}
+
 func TestRawMessage(t *testing.T) {
 	// TODO(rsc): Should not need the * in *RawMessage
 	var data struct {
@@ -388,6 +641,119 @@ func TestDecodeInStream(t *testing.T) {
 
 }
 
+func TestEncodeToken(t *testing.T) {
+	for _, indent := range []string{"", "\t"} {
+	cases:
+		for ci, tcase := range tokenStreamCases {
+			var buf, want bytes.Buffer
+
+			enc := NewEncoder(&buf)
+			enc.SetIndent("", indent)
+			for _, tk := range tcase.expTokens {
+				var err error
+
+				if dt, ok := tk.(decodeThis); ok {
+					if _, ok := dt.v.(error); ok {
+						continue cases
+					}
+					err = enc.Encode(dt.v)
+				} else {
+					err = enc.EncodeToken(tk)
+				}
+				if err != nil {
+					t.Fatalf("case %v: %q got %v", ci, tcase.json, err)
+				}
+			}
+			if err := enc.Flush(); err != nil {
+				t.Fatalf("case %v: %q Flush got %v", ci, tcase.json, err)
+			}
+			Compact(&want, []byte(tcase.json))
+			if indent != "" {
+				compacted := append([]byte(nil), want.Bytes()...)
+				want.Reset()
+				Indent(&want, compacted, "", indent)
+			}
+			want.WriteByte('\n')
+			if buf.String() != want.String() {
+				t.Errorf("case %v: expected %q got %q", ci, want.String(), buf.String())
+			}
+		}
+	}
+
+	for _, tokens := range [][]Token{
+		{Delim(']')},
+		{Delim('['), Delim('}')},
+		{Delim('{'), 1},
+		{Delim('{'), "a", Delim('}')},
+		{Delim('{'), "a", 1, 2},
+		{Delim('('), Delim(')')},
+		{[]int{1}},
+		{RawMessage(`{"a"}`)},
+	} {
+		var buf bytes.Buffer
+		var err error
+
+		enc := NewEncoder(&buf)
+		for _, tk := range tokens {
+			if err = enc.EncodeToken(tk); err != nil {
+				break
+			}
+		}
+		if err == nil {
+			t.Errorf("%v: expected error", tokens)
+		}
+	}
+
+	// a raw message, and a value following an error
+	var buf bytes.Buffer
+	enc := NewEncoder(&buf)
+	for _, tk := range []Token{Delim('{'), "a", RawMessage(` [ 1, 2 ] `), "b"} {
+		if err := enc.EncodeToken(tk); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := enc.EncodeToken(Delim('}')); err == nil {
+		t.Fatal("expected error closing an object after a key")
+	}
+	for _, tk := range []Token{nil, Delim('}')} {
+		if err := enc.EncodeToken(tk); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if buf.String() != "{\"a\":[1,2],\"b\":null}\n" {
+		t.Errorf("got %q", buf.String())
+	}
+}
+
+func TestEncodeTokenFlush(t *testing.T) {
+	var buf bytes.Buffer
+
+	enc := NewEncoder(&buf)
+	if err := enc.EncodeToken(Delim('[')); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; buf.Len() == 0; i++ {
+		if i > tokenFlushSize {
+			t.Fatal("array elements are not being written")
+		}
+		if err := enc.EncodeToken(i); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := enc.EncodeToken(Delim(']')); err != nil {
+		t.Fatal(err)
+	}
+	var ints []int
+	if err := Unmarshal(buf.Bytes(), &ints); err != nil {
+		t.Fatalf("got %v", err)
+	}
+	for i, v := range ints {
+		if v != i {
+			t.Fatalf("element %v is %v", i, v)
+		}
+	}
+}
+
 // Test from golang.org/issue/11893
 func TestHTTPDecoding(t *testing.T) {
 	const raw = `{ "foo": "bar" }`
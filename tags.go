@@ -24,12 +24,17 @@ func parseTag(tag string) (string, tagOptions) {
 // Contains reports whether a comma-separated list of options
 // contains a particular substr flag. substr must be surrounded by a
 // string boundary or commas.
+// A default= option consumes the rest of the list, so that
+// commas in the default value are not taken for other options.
 func (o tagOptions) Contains(optionName string) bool {
 	if len(o) == 0 {
 		return false
 	}
 	s := string(o)
 	for s != "" {
+		if strings.HasPrefix(s, defaultOption) {
+			return false
+		}
 		var next string
 		i := strings.Index(s, ",")
 		if i >= 0 {
@@ -42,3 +47,22 @@ func (o tagOptions) Contains(optionName string) bool {
 	}
 	return false
 }
+
+const defaultOption = "default="
+
+// Default returns the value of the default= option, which
+// extends to the end of the tag, and whether there is one.
+func (o tagOptions) Default() (string, bool) {
+	s := string(o)
+	for s != "" {
+		if strings.HasPrefix(s, defaultOption) {
+			return s[len(defaultOption):], true
+		}
+		i := strings.Index(s, ",")
+		if i < 0 {
+			break
+		}
+		s = s[i+1:]
+	}
+	return "", false
+}
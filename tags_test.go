@@ -26,3 +26,28 @@ func TestTagParsing(t *testing.T) {
 		}
 	}
 }
+
+func TestTagDefault(t *testing.T) {
+	for _, tt := range []struct {
+		tag      string
+		def      string
+		ok       bool
+		required bool
+	}{
+		{"field,omitempty", "", false, false},
+		{"field,required", "", false, true},
+		{"field,default=", "", true, false},
+		{"field,required,default=1", "1", true, true},
+		{"field,default=a,required", "a,required", true, false},
+		{"field,default=[1,2]", "[1,2]", true, false},
+	} {
+		_, opts := parseTag(tt.tag)
+		def, ok := opts.Default()
+		if def != tt.def || ok != tt.ok {
+			t.Errorf("%q: Default() = %q, %v", tt.tag, def, ok)
+		}
+		if opts.Contains("required") != tt.required {
+			t.Errorf("%q: Contains(required) = %v", tt.tag, !tt.required)
+		}
+	}
+}
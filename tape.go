@@ -0,0 +1,228 @@
+//  Copyright (c) 2026 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package json
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+)
+
+// A Tape is a structural index of a JSON document, built in a single pass.
+// Each value in the document is recorded as a node holding its offsets and,
+// for objects and arrays, a pointer to the node following the closing bracket,
+// so that lookups can skip whole subtrees without rescanning them.
+// It is useful when many paths are looked up in the same document.
+type Tape struct {
+	data  []byte
+	nodes []tapeNode
+	stack []int
+}
+
+// node types
+const (
+	tapeObject = iota
+	tapeArray
+	tapeKey
+	tapeString
+	tapeLiteral // numbers, true, false and null
+)
+
+type tapeNode struct {
+	kind  int
+	start int // offset of the first byte of the value
+	end   int // offset past the last byte of the value
+	next  int // index of the node following the value
+}
+
+// NewTape builds the tape for data
+func NewTape(data []byte) (*Tape, error) {
+	tape := &Tape{}
+	err := SetTape(tape, data)
+	if err != nil {
+		return nil, err
+	}
+	return tape, nil
+}
+
+// initialize a Tape, reusing any space already allocated
+func SetTape(tape *Tape, data []byte) error {
+	var sc scanner
+
+	nodes := tape.nodes[0:0]
+	stack := tape.stack[0:0]
+	tape.data = data
+
+	scan := setScanner(&sc, data)
+	scan.reset()
+
+	// index of the literal being scanned, if any
+	literal := -1
+	for scan.offset < len(data) {
+		oldOffset := scan.offset
+		c := data[oldOffset]
+		scan.offset++
+		newOp := scan.step(scan, c)
+
+		// literals end at the first interesting byte following them
+		if literal >= 0 && newOp != scanContinue {
+			nodes[literal].end = oldOffset
+			literal = -1
+		}
+
+		switch newOp {
+		case scanBeginObject, scanBeginArray:
+			kind := tapeObject
+			if newOp == scanBeginArray {
+				kind = tapeArray
+			}
+			stack = append(stack, len(nodes))
+			nodes = append(nodes, tapeNode{kind: kind, start: oldOffset})
+		case scanBeginLiteral:
+			kind := tapeLiteral
+			if c == '"' {
+				kind = tapeString
+				n := len(scan.parseState)
+				if n > 0 && scan.parseState[n-1] == parseObjectKey {
+					kind = tapeKey
+				}
+			}
+			literal = len(nodes)
+			nodes = append(nodes, tapeNode{kind: kind, start: oldOffset, next: literal + 1})
+		case scanEndObject, scanEndArray:
+			n := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			nodes[n].end = scan.offset
+			nodes[n].next = len(nodes)
+		case scanError:
+			tape.nodes = nodes[0:0]
+			tape.stack = stack
+			return scan.err
+		}
+	}
+	if literal >= 0 {
+		nodes[literal].end = len(data)
+	}
+	tape.nodes = nodes
+	tape.stack = stack
+	if scan.eof() == scanError {
+		tape.nodes = nodes[0:0]
+		return scan.err
+	}
+	return nil
+}
+
+// release tape
+func (tape *Tape) Release() {
+	tape.data = nil
+	tape.nodes = tape.nodes[0:0]
+	tape.stack = tape.stack[0:0]
+}
+
+// Find a first level field
+func (tape *Tape) FindKey(field string) ([]byte, error) {
+	if field == "" {
+		return tape.data, nil
+	}
+	if len(tape.nodes) == 0 {
+		return nil, nil
+	}
+	return tape.value(tape.findKey(0, field)), nil
+}
+
+// Find an array element
+func (tape *Tape) FindIndex(index int) ([]byte, error) {
+	if index < 0 {
+		return nil, fmt.Errorf("invalid array index")
+	}
+	if len(tape.nodes) == 0 {
+		return nil, nil
+	}
+	return tape.value(tape.findIndex(0, index)), nil
+}
+
+// Find a section of raw JSON by specifying a JSONPointer.
+func (tape *Tape) Find(path string) ([]byte, error) {
+	if path == "" {
+		return tape.data, nil
+	}
+	if len(tape.nodes) == 0 {
+		return nil, nil
+	}
+
+	n := 0
+	for _, p := range parsePointer(path) {
+		switch tape.nodes[n].kind {
+		case tapeObject:
+			n = tape.findKey(n, p)
+		case tapeArray:
+			i, err := strconv.Atoi(p)
+			if err != nil || i < 0 {
+				return nil, nil
+			}
+			n = tape.findIndex(n, i)
+		default:
+			n = -1
+		}
+		if n < 0 {
+			return nil, nil
+		}
+	}
+	return tape.value(n), nil
+}
+
+func (tape *Tape) value(n int) []byte {
+	if n < 0 {
+		return nil
+	}
+	node := &tape.nodes[n]
+	return tape.data[node.start:node.end]
+}
+
+// findKey returns the node of the value associated with field in the object
+// at node n, or -1 if n is not an object or the field is not found
+func (tape *Tape) findKey(n int, field string) int {
+	if tape.nodes[n].kind != tapeObject {
+		return -1
+	}
+	end := tape.nodes[n].next
+	for i := n + 1; i < end; i = tape.nodes[i+1].next {
+		if tape.keyEquals(i, field) {
+			return i + 1
+		}
+	}
+	return -1
+}
+
+// findIndex returns the node of the index-th element of the array
+// at node n, or -1 if n is not an array or the element is not found
+func (tape *Tape) findIndex(n int, index int) int {
+	if tape.nodes[n].kind != tapeArray {
+		return -1
+	}
+	end := tape.nodes[n].next
+	for i := n + 1; i < end; i = tape.nodes[i].next {
+		if index == 0 {
+			return i
+		}
+		index--
+	}
+	return -1
+}
+
+func (tape *Tape) keyEquals(n int, field string) bool {
+	node := &tape.nodes[n]
+	key := tape.data[node.start+1 : node.end-1]
+	if bytes.IndexByte(key, '\\') < 0 {
+		return string(key) == field
+	}
+	res, ok := unquoteBytes(tape.data[node.start:node.end])
+	return ok && string(res) == field
+}
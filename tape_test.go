@@ -0,0 +1,179 @@
+//  Copyright (c) 2026 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package json
+
+import (
+	"bytes"
+	"testing"
+)
+
+// tests
+
+func TestTapeFindKey(t *testing.T) {
+	tape, err := NewTape(keysDoc)
+	if err != nil {
+		t.Fatalf("NewTape got %v", err)
+	}
+	for _, test := range keysTests {
+		res, err := tape.FindKey(test.field)
+		if err != nil {
+			t.Fatalf("field %q got %v", test.field, err)
+		}
+		if string(res) != test.res {
+			t.Fatalf("field %q expected %q found %q", test.field, test.res, res)
+		}
+	}
+	res, err := tape.FindKey("f99")
+	if err != nil || res != nil {
+		t.Fatalf("field f99 expected nothing found %q %v", res, err)
+	}
+	res, err = tape.FindKey("a")
+	if err != nil || res != nil {
+		t.Fatalf("nested field a expected nothing found %q %v", res, err)
+	}
+
+	SetTape(tape, []byte("[ \"f1\" ]"))
+	res, err = tape.FindKey("f1")
+	if err != nil || res != nil {
+		t.Fatalf("mixing field and array element %q %v", res, err)
+	}
+}
+
+func TestTapeFindIndex(t *testing.T) {
+	tape, err := NewTape([]byte(" [ 1, {\"a\": [2, 3]}, \"x\" , [], null ] "))
+	if err != nil {
+		t.Fatalf("NewTape got %v", err)
+	}
+	for i, exp := range []string{"1", "{\"a\": [2, 3]}", "\"x\"", "[]", "null"} {
+		res, err := tape.FindIndex(i)
+		if err != nil {
+			t.Fatalf("index %v got %v", i, err)
+		}
+		if string(res) != exp {
+			t.Fatalf("index %v expected %q found %q", i, exp, res)
+		}
+	}
+	res, err := tape.FindIndex(5)
+	if err != nil || res != nil {
+		t.Fatalf("index 5 expected nothing found %q %v", res, err)
+	}
+	_, err = tape.FindIndex(-1)
+	if err == nil {
+		t.Fatalf("negative index expected error")
+	}
+}
+
+func TestTapeFind(t *testing.T) {
+	var tape Tape
+
+	obj := []byte(objSrc)
+	err := SetTape(&tape, obj)
+	if err != nil {
+		t.Fatalf("SetTape got %v", err)
+	}
+	for _, test := range tests {
+		exp, err := Find(obj, test.path)
+		if err != nil {
+			t.Fatalf("Find %q got %v", test.path, err)
+		}
+		res, err := tape.Find(test.path)
+		if err != nil {
+			t.Fatalf("path %q got %v", test.path, err)
+		}
+		if !bytes.Equal(res, exp) {
+			t.Errorf("path %q expected %q found %q", test.path, exp, res)
+		}
+	}
+
+	if codeJSON == nil {
+		codeInit()
+	}
+	err = SetTape(&tape, codeJSON)
+	if err != nil {
+		t.Fatalf("SetTape code.json got %v", err)
+	}
+	for _, path := range []string{"/tree/kids/0/kids/1/name", "/tree/kids/3/touches", "/username", "/tree/kids/99999"} {
+		exp, err := Find(codeJSON, path)
+		if err != nil {
+			t.Fatalf("Find %q got %v", path, err)
+		}
+		res, err := tape.Find(path)
+		if err != nil {
+			t.Fatalf("path %q got %v", path, err)
+		}
+		if !bytes.Equal(res, exp) {
+			t.Errorf("path %q expected %q found %q", path, exp, res)
+		}
+	}
+	tape.Release()
+}
+
+func TestTapeErrors(t *testing.T) {
+	for _, doc := range []string{"", "{", "[1,]", "{\"a\" 1}", "[1] x", "\"abc"} {
+		_, err := NewTape([]byte(doc))
+		if err == nil {
+			t.Errorf("%q expected error", doc)
+		}
+	}
+	for _, doc := range []string{"1", " true ", "\"abc\"", "{}", "[]"} {
+		tape, err := NewTape([]byte(doc))
+		if err != nil {
+			t.Fatalf("%q got %v", doc, err)
+		}
+		res, err := tape.Find("/a")
+		if err != nil || res != nil {
+			t.Errorf("%q expected nothing found %q %v", doc, res, err)
+		}
+	}
+}
+
+// benchmarks
+
+func BenchmarkTapeFindKey(b *testing.B) {
+	var tape Tape
+
+	obj := []byte(objSrc)
+	b.SetBytes(int64(len(obj)))
+	SetTape(&tape, obj)
+	for i := 0; i < b.N; i++ {
+		for _, test := range keytests {
+			tape.FindKey(test.path)
+		}
+	}
+}
+
+func BenchmarkTapeFindKeyRepeat(b *testing.B) {
+	var tape Tape
+
+	obj := []byte(objSrc)
+	b.SetBytes(int64(len(obj)))
+	for i := 0; i < b.N; i++ {
+		SetTape(&tape, obj)
+		for _, test := range keytests {
+			tape.FindKey(test.path)
+		}
+		tape.Release()
+	}
+}
+
+func BenchmarkTapeFindCode(b *testing.B) {
+	var tape Tape
+
+	if codeJSON == nil {
+		b.StopTimer()
+		codeInit()
+		b.StartTimer()
+	}
+	SetTape(&tape, codeJSON)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		tape.Find("/tree/kids/3/kids/2/name")
+	}
+}
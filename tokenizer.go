@@ -0,0 +1,92 @@
+//  Copyright (c) 2026 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package json
+
+import (
+	"io"
+)
+
+// A Tokenizer returns the events of a JSON document held in memory, one at a time.
+// The Raw field of the events it returns points into the document, which
+// makes it usable as a building block for custom consumers: once its parse
+// stack has grown to the nesting depth of the documents, it never allocates.
+type Tokenizer struct {
+	scan scanner
+}
+
+// NewTokenizer returns a new tokenizer for data
+func NewTokenizer(data []byte) *Tokenizer {
+	tok := &Tokenizer{}
+	SetTokenizer(tok, data)
+	return tok
+}
+
+// initialize a Tokenizer, preserving its parse stack for reuse
+func SetTokenizer(tok *Tokenizer, data []byte) {
+	parseState := tok.scan.parseState
+	setScanner(&tok.scan, data)
+	tok.scan.parseState = parseState
+	tok.scan.reset()
+}
+
+// Next returns the next event in the document.
+// At the end of the document, Next returns io.EOF.
+func (tok *Tokenizer) Next() (Event, error) {
+	scan := &tok.scan
+	for scan.offset < len(scan.data) {
+		i := scan.offset
+		c := scan.data[i]
+		scan.offset++
+
+		switch scan.step(scan, c) {
+		case scanBeginLiteral:
+			kind := literalKind(c, scan)
+
+			// consume the literal, up to the first byte that does not belong to it
+			for scan.offset < len(scan.data) {
+				c := scan.data[scan.offset]
+				scan.offset++
+				newOp := scan.step(scan, c)
+				if newOp != scanContinue {
+					if newOp == scanError {
+						return Event{}, scan.err
+					}
+					scan.undo(newOp)
+					break
+				}
+			}
+			return Event{Kind: kind, Raw: scan.data[i:scan.offset], Offset: int64(i)}, nil
+		case scanBeginObject:
+			return Event{Kind: EventBeginObject, Offset: int64(i)}, nil
+		case scanEndObject:
+			return Event{Kind: EventEndObject, Offset: int64(i)}, nil
+		case scanBeginArray:
+			return Event{Kind: EventBeginArray, Offset: int64(i)}, nil
+		case scanEndArray:
+			return Event{Kind: EventEndArray, Offset: int64(i)}, nil
+		case scanError:
+			return Event{}, scan.err
+		}
+	}
+	if scan.eof() == scanError {
+		return Event{}, scan.err
+	}
+	return Event{}, io.EOF
+}
+
+// Depth returns the number of objects and arrays currently open
+func (tok *Tokenizer) Depth() int {
+	return len(tok.scan.parseState)
+}
+
+// Offset returns the offset of the first byte not yet consumed
+func (tok *Tokenizer) Offset() int {
+	return tok.scan.offset
+}
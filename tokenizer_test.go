@@ -0,0 +1,118 @@
+//  Copyright (c) 2026 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package json
+
+import (
+	"io"
+	"reflect"
+	"testing"
+)
+
+// tests
+
+func TestTokenizer(t *testing.T) {
+	var events []string
+
+	// the first value of the push parser document
+	tok := NewTokenizer(pushDoc[:71])
+	for {
+		ev, err := tok.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Next got %v", err)
+		}
+		events = append(events, pushEventString(ev))
+	}
+	if !reflect.DeepEqual(events, pushEvents[:18]) {
+		t.Fatalf("expected %q got %q", pushEvents[:18], events)
+	}
+	if tok.Depth() != 0 || tok.Offset() != 71 {
+		t.Fatalf("expected depth 0 offset 71, got %v %v", tok.Depth(), tok.Offset())
+	}
+
+	for _, in := range []string{"12", " -1.5e3 ", "\"s\"", "null"} {
+		SetTokenizer(tok, []byte(in))
+		ev, err := tok.Next()
+		if err != nil {
+			t.Fatalf("%q got %v", in, err)
+		}
+		if ev.Raw == nil || string(ev.Raw) != string(in[ev.Offset:int(ev.Offset)+len(ev.Raw)]) {
+			t.Fatalf("%q got %v", in, pushEventString(ev))
+		}
+		if _, err = tok.Next(); err != io.EOF {
+			t.Fatalf("%q expected EOF, got %v", in, err)
+		}
+	}
+}
+
+func TestTokenizerErrors(t *testing.T) {
+	for _, in := range []string{"", "[1,]", "{\"a\" 1}", "[1] 2", "[\"abc", "[tru]", "{"} {
+		var err error
+
+		tok := NewTokenizer([]byte(in))
+		for err == nil {
+			_, err = tok.Next()
+		}
+		if _, ok := err.(*SyntaxError); !ok {
+			t.Errorf("%q expected syntax error, got %v", in, err)
+		}
+	}
+}
+
+func TestTokenizerAllocs(t *testing.T) {
+	var tok Tokenizer
+
+	if codeJSON == nil {
+		codeInit()
+	}
+	allocs := testing.AllocsPerRun(10, func() {
+		SetTokenizer(&tok, codeJSON)
+		for {
+			_, err := tok.Next()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				t.Fatalf("Next got %v", err)
+			}
+		}
+	})
+	if allocs != 0 {
+		t.Fatalf("expected no allocations, got %v", allocs)
+	}
+}
+
+// benchmarks
+
+func BenchmarkTokenizerCode(b *testing.B) {
+	var tok Tokenizer
+
+	if codeJSON == nil {
+		b.StopTimer()
+		codeInit()
+		b.StartTimer()
+	}
+	b.SetBytes(int64(len(codeJSON)))
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		SetTokenizer(&tok, codeJSON)
+		for {
+			_, err := tok.Next()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				b.Fatal("Next:", err)
+			}
+		}
+	}
+}